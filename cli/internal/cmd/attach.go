@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runAttach(args []string) error {
+	fs := newFlagSet("attach")
+	attachMode := attachModeFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	mode, err := attachMode()
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("attach requires exactly one <session|slug>")
+	}
+	target := positional[0]
+
+	entry, err := resolveEntry(workspace.Load(workspace.LoadConfig()), target)
+	if err != nil {
+		// Unmanaged sessions can still be attached by their exact name
+		var ee *exitError
+		if errors.As(err, &ee) && ee.code == ExitNotFound && tmux.HasSession(target) {
+			return attachSession(&ui.AttachAction{SessionName: target, Mode: mode})
+		}
+		return err
+	}
+
+	return attachSession(&ui.AttachAction{SessionName: entry.SessionName, Cwd: entry.Path, Entry: *entry, Mode: mode})
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runCleanup(args []string) error {
+	fs := newFlagSet("cleanup")
+	dryRun := fs.Bool("dry-run", false, "only report orphans")
+	yes := fs.Bool("yes", false, "clean up without asking")
+	force := fs.Bool("force", false, "also remove orphan worktrees with uncommitted changes or unmerged commits")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg := workspace.LoadConfig()
+	repos := discovery.FindGitRepos(cfg.SearchPaths, cfg.Depth)
+	sessions, _ := tmux.ListSessions()
+	orphans := task.FindOrphans(workspace.Match(repos, sessions), sessions)
+
+	if len(orphans) == 0 {
+		fmt.Fprintln(stdout, "No orphans found.")
+		return nil
+	}
+
+	for _, o := range orphans {
+		fmt.Fprintf(stdout, "%-8s  %s  (%s)\n", o.Kind, o.SessionName, o.Reason())
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !isInteractive() {
+		return usageErrorf("refusing to clean up without a terminal (use --yes or --dry-run)")
+	}
+
+	var failed int
+	for _, o := range orphans {
+		if err := cleanupOrphan(o, *yes, *force); err != nil {
+			fmt.Fprintf(stderr, "twt: %s: %v\n", o.SessionName, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d orphan(s) could not be cleaned up", failed)
+	}
+	return nil
+}
+
+func cleanupOrphan(o task.Orphan, yes, force bool) error {
+	switch o.Kind {
+	case task.OrphanSession:
+		if !yes {
+			ok, err := confirm(fmt.Sprintf("Session %q has no worktree. Kill it?", o.SessionName))
+			if err != nil || !ok {
+				return err
+			}
+		}
+		if err := tmux.KillSession(o.SessionName); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "killed session %s\n", o.SessionName)
+
+	case task.OrphanWorktree:
+		check := task.Check(o.Entry)
+		question := fmt.Sprintf("Worktree %q has no session. Remove it?", o.Path)
+		if !check.Safe() {
+			if yes && !force {
+				fmt.Fprintf(stderr, "twt: skipping %s: %s (use --force)\n",
+					o.Path, strings.Join(check.Problems(), ", "))
+				return nil
+			}
+			question = fmt.Sprintf("Worktree %q has %s. Remove anyway?",
+				o.Path, strings.Join(check.Problems(), ", "))
+		}
+		if !yes {
+			ok, err := confirm(question)
+			if err != nil || !ok {
+				return err
+			}
+		}
+		if err := task.Remove(o.Entry, task.RemoveOptions{Force: !check.Safe()}); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "removed worktree %s\n", o.Path)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// argKind describes what a command's positional argument completes to.
+type argKind int
+
+const (
+	argNone   argKind = iota
+	argTarget         // session names and task slugs
+	argShell          // supported completion shells
+)
+
+// completionCacheAge bounds how stale repository discovery may be during
+// completion. Sessions are always listed live.
+const completionCacheAge = time.Minute
+
+// flagValues lists flags whose value can be completed dynamically.
+var flagValues = map[string]func() []string{
+	"--repo":    completeRepos,
+	"-repo":     completeRepos,
+	"--profile": completeProfiles,
+	"-profile":  completeProfiles,
+	"--layout":  completeLayouts,
+	"-layout":   completeLayouts,
+}
+
+// The scripts delegate to the hidden `twt __complete` command, passing the
+// words typed so far with the word being completed last.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for twt
+_twt_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($(twt __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -F _twt_complete twt
+`,
+	"zsh": `#compdef twt
+# zsh completion for twt
+_twt() {
+    local -a candidates
+    candidates=(${(f)"$(twt __complete "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+if [[ "$funcstack[1]" = "_twt" ]]; then
+    _twt "$@"
+else
+    compdef _twt twt
+fi
+`,
+	"fish": `# fish completion for twt
+function __twt_complete
+    set -l tokens (commandline -opc)
+    set -e tokens[1]
+    twt __complete $tokens (commandline -ct) 2>/dev/null
+end
+complete -c twt -f -a '(__twt_complete)'
+`,
+}
+
+func runCompletion(args []string) error {
+	fs := newFlagSet("completion")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("completion requires exactly one shell: bash, zsh or fish")
+	}
+
+	script, ok := completionScripts[positional[0]]
+	if !ok {
+		return usageErrorf("unsupported shell %q: use bash, zsh or fish", positional[0])
+	}
+	_, err = fmt.Fprint(stdout, script)
+	return err
+}
+
+// runComplete prints the candidates for the last word in args, one per line.
+func runComplete(args []string) error {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	current := args[len(args)-1]
+	previous := args[:len(args)-1]
+
+	// Complete sessions from the server the finished command would use
+	scanServerFlags(previous)
+	if err := selectServer(); err != nil {
+		return nil
+	}
+
+	for _, candidate := range completions(previous, current) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Fprintln(stdout, candidate)
+		}
+	}
+	return nil
+}
+
+// scanServerFlags picks --socket and --profile out of a partial command
+// line, which may hold flags of any command.
+func scanServerFlags(words []string) {
+	for i, word := range words {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+		if !strings.HasPrefix(word, "-") || (name != "socket" && name != "profile") {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(words) {
+				continue
+			}
+			value = words[i+1]
+		}
+
+		if name == "socket" {
+			serverFlags.socket = value
+		} else {
+			serverFlags.profile = value
+		}
+	}
+}
+
+func completions(previous []string, current string) []string {
+	if len(previous) == 0 {
+		var names []string
+		for _, c := range commands {
+			if !c.hidden {
+				names = append(names, c.name)
+			}
+		}
+		return names
+	}
+
+	if values, ok := flagValues[previous[len(previous)-1]]; ok {
+		return values()
+	}
+	if strings.HasPrefix(current, "-") {
+		return nil
+	}
+
+	for _, c := range commands {
+		if c.name != previous[0] {
+			continue
+		}
+		switch c.args {
+		case argTarget:
+			return completeTargets()
+		case argShell:
+			return []string{"bash", "fish", "zsh"}
+		}
+	}
+	return nil
+}
+
+// completeTargets returns every session name and task slug, deduplicated.
+func completeTargets() []string {
+	entries, sessions := workspace.LoadCached(workspace.LoadConfig(), completionCacheAge)
+
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.SessionName] = true
+		if !e.IsRoot {
+			seen[e.Slug] = true
+		}
+	}
+	for _, s := range sessions {
+		seen[s.Name] = true
+	}
+	return sortedKeys(seen)
+}
+
+func completeRepos() []string {
+	entries, _ := workspace.LoadCached(workspace.LoadConfig(), completionCacheAge)
+
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		seen[e.RepoName] = true
+	}
+	return sortedKeys(seen)
+}
+
+func completeProfiles() []string {
+	seen := make(map[string]bool)
+	for name := range workspace.LoadConfig().Profiles {
+		seen[name] = true
+	}
+	return sortedKeys(seen)
+}
+
+func completeLayouts() []string {
+	seen := make(map[string]bool)
+	for name := range workspace.LoadConfig().Layouts {
+		seen[name] = true
+	}
+	return sortedKeys(seen)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func TestScanServerFlags(t *testing.T) {
+	tests := []struct {
+		words   []string
+		socket  string
+		profile string
+	}{
+		{[]string{"attach", "api_auth"}, "", ""},
+		{[]string{"attach", "--socket", "work"}, "work", ""},
+		{[]string{"attach", "-socket=/tmp/tmux.sock"}, "/tmp/tmux.sock", ""},
+		{[]string{"run", "--profile", "ci", "--repo", "api"}, "", "ci"},
+		{[]string{"attach", "--socket"}, "", ""},   // value not typed yet
+		{[]string{"attach", "-L", "work"}, "", ""}, // tmux's flag, not twt's
+	}
+	for _, tt := range tests {
+		serverFlags.socket, serverFlags.profile = "", ""
+		scanServerFlags(tt.words)
+		if serverFlags.socket != tt.socket || serverFlags.profile != tt.profile {
+			t.Errorf("scanServerFlags(%q) = socket %q, profile %q; want %q, %q",
+				tt.words, serverFlags.socket, serverFlags.profile, tt.socket, tt.profile)
+		}
+	}
+	serverFlags.socket, serverFlags.profile = "", ""
+}
+
+// setupCompletion scripts a config with one repository, its worktrees and
+// tmux sessions, and returns the tmux fake.
+func setupCompletion(t *testing.T) *runner.Fake {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+	t.Setenv("TMUX", "")
+
+	src := filepath.Join(home, "src")
+	repo := filepath.Join(src, "api")
+	auth := filepath.Join(repo, ".worktrees", "auth")
+	for _, dir := range []string{filepath.Join(repo, ".git"), auth, filepath.Join(home, ".config", "tmux-worktree-tui")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := `{"search_paths": ["` + src + `"], "depth": 2,
+		"profiles": {"work": {"socket": "work"}, "ci": {"socket": "ci"}},
+		"layouts": {"dev": {"windows": []}, "ops": {"windows": []}}}`
+	if err := os.WriteFile(filepath.Join(home, ".config", "tmux-worktree-tui", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	porcelain := "worktree " + repo + "\nbranch refs/heads/main\n\n" +
+		"worktree " + auth + "\nbranch refs/heads/task/auth\n\n"
+	fakeGit := runner.NewFake(
+		runner.Step{Argv: []string{"git", "worktree", "list", "--porcelain"}, Stdout: porcelain, Repeat: true},
+	)
+	sessions := "api_auth|||$1|||2|||1|||0|||0|||0|||||||||" + auth + "|||" + auth + "\n" +
+		"scratch|||$2|||1|||0|||0|||0|||0|||||||||/tmp|||\n"
+	fakeTmux := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "list-sessions", "*"}, Stdout: sessions, Repeat: true},
+	)
+
+	oldGit, oldTmux, oldServer := git.Runner, tmux.Runner, tmux.Server
+	git.Runner, tmux.Runner = fakeGit, fakeTmux
+	t.Cleanup(func() { git.Runner, tmux.Runner, tmux.Server = oldGit, oldTmux, oldServer })
+	return fakeTmux
+}
+
+func TestCompletions(t *testing.T) {
+	setupCompletion(t)
+
+	tests := []struct {
+		previous []string
+		current  string
+		want     []string
+	}{
+		{[]string{"attach"}, "", []string{"api_auth", "api_main", "auth", "scratch"}},
+		{[]string{"attach", "-d"}, "", []string{"api_auth", "api_main", "auth", "scratch"}},
+		{[]string{"attach"}, "-", nil},
+		{[]string{"new", "--layout"}, "", []string{"dev", "ops"}},
+		{[]string{"attach", "--profile"}, "", []string{"ci", "work"}},
+		{[]string{"list", "-repo"}, "", []string{"api"}},
+		{[]string{"completion"}, "", []string{"bash", "fish", "zsh"}},
+		{[]string{"list"}, "", nil},
+	}
+	for _, tt := range tests {
+		if got := completions(tt.previous, tt.current); !slices.Equal(got, tt.want) {
+			t.Errorf("completions(%q, %q) = %q, want %q", tt.previous, tt.current, got, tt.want)
+		}
+	}
+
+	names := completions(nil, "")
+	if !slices.Contains(names, "attach") || !slices.Contains(names, "completion") {
+		t.Errorf("command names = %q", names)
+	}
+	if slices.Contains(names, "__complete") {
+		t.Error("hidden commands should not be offered")
+	}
+}
+
+func TestRunComplete(t *testing.T) {
+	fakeTmux := setupCompletion(t)
+	var out bytes.Buffer
+	old := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = old })
+
+	if err := runComplete([]string{"attach", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Fields(out.String()), []string{"api_auth", "api_main", "auth"}; !slices.Equal(got, want) {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	if n := len(fakeTmux.Argvs()); n != 1 {
+		t.Errorf("ran tmux %d times, want a single list-sessions: %q", n, fakeTmux.Argvs())
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/doctor"
+)
+
+func runDoctor(args []string) error {
+	fs := newFlagSet("doctor")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	checks := doctor.Run()
+
+	if *asJSON {
+		if err := writeJSON(struct {
+			SchemaVersion int            `json:"schema_version"`
+			OK            bool           `json:"ok"`
+			Checks        []doctor.Check `json:"checks"`
+		}{SchemaVersion, !doctor.Failed(checks), checks}); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range checks {
+			fmt.Fprintf(stdout, "[%s] %s: %s\n", strings.ToUpper(string(c.Status)), c.Name, c.Message)
+		}
+	}
+
+	if doctor.Failed(checks) {
+		return &exitError{code: ExitError, err: fmt.Errorf("doctor found problems")}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/ports"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runList(args []string) error {
+	fs := newFlagSet("list")
+	repo := fs.String("repo", "", "only list worktrees of this repository (name or path)")
+	format := formatFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	outFormat, err := format()
+	if err != nil {
+		return err
+	}
+
+	cfg := workspace.LoadConfig()
+	var entries []workspace.Entry
+	if *repo != "" {
+		root, err := resolveRepo(*repo)
+		if err != nil {
+			return err
+		}
+		entries = workspace.LoadRepo(root)
+	} else {
+		entries = workspace.Load(cfg)
+	}
+
+	// The registry only matters when ports are configured, and a broken one
+	// should not keep the worktrees from being listed
+	var reserved *ports.Registry
+	if outFormat != formatTable && cfg.Ports != nil {
+		if reserved, err = ports.LoadRegistry(); err != nil {
+			fmt.Fprintf(stderr, "twt: %v\n", err)
+		}
+	}
+
+	switch outFormat {
+	case formatJSON:
+		items := []worktreeJSON{}
+		for _, e := range entries {
+			e.LoadDetails()
+			item := newWorktreeJSON(e)
+			item.Ports = reserved.Lookup(e.Path)
+			items = append(items, item)
+		}
+		return writeJSON(struct {
+			SchemaVersion int            `json:"schema_version"`
+			Worktrees     []worktreeJSON `json:"worktrees"`
+		}{SchemaVersion, items})
+
+	case formatNDJSON:
+		enc := json.NewEncoder(stdout)
+		for _, e := range entries {
+			e.LoadDetails()
+			item := newWorktreeJSON(e)
+			item.SchemaVersion = SchemaVersion
+			item.Ports = reserved.Lookup(e.Path)
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tBRANCH\tSTATE\tPATH")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.SessionName, e.Branch, entryState(e), e.Path)
+	}
+	return w.Flush()
+}
+
+// entryState summarizes the session state of an entry in one word.
+func entryState(e workspace.Entry) string {
+	switch {
+	case e.Session == nil:
+		return "-"
+	case e.Session.Attached:
+		return "attached"
+	default:
+		return "running"
+	}
+}
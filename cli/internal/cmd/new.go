@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runNew(args []string) error {
+	fs := newFlagSet("new")
+	repo := fs.String("repo", "", "repository to create the task in (name or path, default: current)")
+	base := fs.String("base", "", "start point of the task branch (default: origin/main or main)")
+	attach := fs.Bool("attach", false, "attach to the session after creating it")
+	layoutName := fs.String("layout", "", "session layout from the config (default: the repo's or the global layout)")
+	attachMode := attachModeFlags(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("new requires exactly one <slug>")
+	}
+
+	slug := naming.NormalizeSlug(positional[0])
+	if err := naming.ValidateSlug(slug); err != nil {
+		return &exitError{code: ExitUsage, err: err}
+	}
+
+	// The mode reads the config for its default, which only matters when
+	// attaching; check it before anything is created
+	var mode tmux.AttachMode
+	if *attach {
+		if mode, err = attachMode(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux not found: install tmux first")
+	}
+
+	repoRoot, err := resolveRepo(*repo)
+	if err != nil {
+		return err
+	}
+
+	baseBranch := *base
+	if baseBranch == "" {
+		baseBranch, err = git.GetBaseBranch(repoRoot)
+		if err != nil {
+			return err
+		}
+	} else if !git.RefExists(repoRoot, baseBranch) {
+		return notFoundErrorf("base %q does not exist", baseBranch)
+	}
+
+	cfg := workspace.LoadConfig()
+	sessionLayout, err := layout.Select(cfg, *layoutName, naming.GetRepoName(repoRoot), repoRoot)
+	if err != nil {
+		return &exitError{code: ExitUsage, err: err}
+	}
+
+	finalSlug := uniqueSlug(repoRoot, slug)
+
+	worktreePath, err := git.AddWorktree(repoRoot, finalSlug, baseBranch)
+	if err != nil {
+		return err
+	}
+	workspace.ClearCache()
+
+	env, err := layout.Env(cfg, layout.Worktree{
+		Repo:     naming.GetRepoName(repoRoot),
+		RepoPath: repoRoot,
+		Slug:     finalSlug,
+		Branch:   "task/" + finalSlug,
+		Path:     worktreePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	sessionName := sessionNameFor(repoRoot, finalSlug)
+	if err := layout.CreateSession(sessionName, worktreePath, sessionLayout, env); err != nil {
+		return err
+	}
+
+	if finalSlug != slug {
+		fmt.Fprintf(stderr, "twt: %q is taken, created %q instead\n", slug, finalSlug)
+	}
+	fmt.Fprintln(stdout, worktreePath)
+
+	if *attach {
+		return attachSession(&ui.AttachAction{SessionName: sessionName, Cwd: worktreePath, Mode: mode})
+	}
+	return nil
+}
+
+// uniqueSlug appends -2, -3, ... to slug until it is free, shortening
+// slug where the suffix would not fit otherwise.
+func uniqueSlug(repoRoot, slug string) string {
+	candidate := slug
+	for suffix := 2; task.SlugTaken(repoRoot, candidate); suffix++ {
+		candidate = naming.NumberedSlug(slug, suffix)
+	}
+	return candidate
+}
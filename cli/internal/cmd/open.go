@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/editor"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runOpen(args []string) error {
+	fs := newFlagSet("open")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("open requires exactly one <session|slug>")
+	}
+	target := positional[0]
+
+	cfg := workspace.LoadConfig()
+	var repoName, repoPath, path string
+
+	entry, err := resolveEntry(workspace.Load(cfg), target)
+	if err != nil {
+		// Unmanaged sessions open their working directory
+		var ee *exitError
+		if !errors.As(err, &ee) || ee.code != ExitNotFound || !tmux.HasSession(target) {
+			return err
+		}
+		if path, err = tmux.GetSessionWorkdir(target); err != nil || path == "" {
+			return notFoundErrorf("session %q has no working directory", target)
+		}
+	} else {
+		repoName, repoPath, path = entry.RepoName, entry.RepoPath, entry.Path
+	}
+
+	cmd, err := editor.Command(cfg, repoName, repoPath, path)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// pickerFlagNames are the flags registered by pickerFlags, in the order
+// they are forwarded to a relaunched picker.
+var pickerFlagNames = []string{"tab", "sort", "dirty", "query", "repo", "d", "r", "grouped"}
+
+// pickerFlags registers the flags that set the picker's initial state and
+// returns a function that resolves them after parsing.
+func pickerFlags(fs *flag.FlagSet) func() (ui.Options, error) {
+	tab := fs.String("tab", "projects", "initial tab: projects or sessions")
+	sortBy := fs.String("sort", "name", "initial sort order: name, recent or active")
+	dirty := fs.Bool("dirty", false, "only show worktrees with uncommitted changes")
+	query := fs.String("query", "", "pre-fill the list filter")
+	repo := fs.String("repo", "", "only show worktrees of this repository")
+	attachMode := attachModeFlags(fs)
+
+	return func() (ui.Options, error) {
+		opts := ui.Options{DirtyOnly: *dirty, Query: *query, Repo: *repo}
+
+		var err error
+		if opts.Attach, err = attachMode(); err != nil {
+			return opts, err
+		}
+		if opts.Tab, err = ui.ParseTab(*tab); err != nil {
+			return opts, &exitError{code: ExitUsage, err: err}
+		}
+		if opts.Sort, err = ui.ParseSort(*sortBy); err != nil {
+			return opts, &exitError{code: ExitUsage, err: err}
+		}
+		return opts, nil
+	}
+}
+
+// attachModeFlags registers -d, -r and --grouped and returns a function
+// that resolves the attach mode after parsing, falling back to the mode in
+// the config.
+func attachModeFlags(fs *flag.FlagSet) func() (tmux.AttachMode, error) {
+	detach := fs.Bool("d", false, "detach the session's other clients")
+	readOnly := fs.Bool("r", false, "attach read-only")
+	grouped := fs.Bool("grouped", false, "attach to a new session grouped with the target, with its own current window")
+
+	return func() (tmux.AttachMode, error) {
+		mode, set := tmux.AttachShared, 0
+		for _, f := range []struct {
+			on   bool
+			mode tmux.AttachMode
+		}{{*detach, tmux.AttachDetachOthers}, {*readOnly, tmux.AttachReadOnly}, {*grouped, tmux.AttachGrouped}} {
+			if f.on {
+				mode, set = f.mode, set+1
+			}
+		}
+
+		switch {
+		case set > 1:
+			return mode, usageErrorf("-d, -r and --grouped cannot be combined")
+		case set == 1:
+			return mode, nil
+		}
+
+		mode, err := tmux.ParseAttachMode(workspace.LoadConfig().Attach)
+		if err != nil {
+			return mode, fmt.Errorf("config: %w", err)
+		}
+		return mode, nil
+	}
+}
+
+// forwardPickerFlags returns the picker flags that were set on fs as
+// arguments, so a relaunched twt starts in the same state.
+func forwardPickerFlags(fs *flag.FlagSet) []string {
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = f.Value.String()
+	})
+
+	var args []string
+	for _, name := range pickerFlagNames {
+		if value, ok := set[name]; ok {
+			args = append(args, "--"+name+"="+value)
+		}
+	}
+	return args
+}
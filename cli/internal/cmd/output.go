@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// SchemaVersion is the version of the --json/--ndjson output format.
+// Fields may be added within a version; renaming or removing a field, or
+// changing its meaning, bumps the version.
+const SchemaVersion = 1
+
+// worktreeJSON is the machine-readable form of a workspace.Entry.
+type worktreeJSON struct {
+	SchemaVersion int         `json:"schema_version,omitempty"` // set on NDJSON lines only
+	Repo          string      `json:"repo"`
+	RepoPath      string      `json:"repo_path"`
+	Path          string      `json:"path"`
+	Branch        string      `json:"branch"`
+	Slug          string      `json:"slug"`
+	SessionName   string      `json:"session_name"`
+	IsRoot        bool        `json:"is_root"`
+	HasSession    bool        `json:"has_session"`
+	Windows       int         `json:"windows"`
+	Attached      bool        `json:"attached"`
+	Dirty         bool        `json:"dirty"`
+	Status        *statusJSON `json:"status"`      // null when git status failed
+	RecentTime    *time.Time  `json:"recent_time"` // null when unknown
+	Ports         []int       `json:"ports"`       // reserved block, null when none
+}
+
+type statusJSON struct {
+	Modified  int `json:"modified"`
+	Added     int `json:"added"`
+	Deleted   int `json:"deleted"`
+	Untracked int `json:"untracked"`
+}
+
+type sessionJSON struct {
+	SchemaVersion int    `json:"schema_version,omitempty"` // set on NDJSON lines only
+	Name          string `json:"name"`
+	Windows       int    `json:"windows"`
+	Attached      bool   `json:"attached"`
+	Workdir       string `json:"workdir"`
+	Path          string `json:"path"`
+
+	Created      *time.Time `json:"created"`
+	Activity     *time.Time `json:"activity"`
+	LastAttached *time.Time `json:"last_attached"` // null if never attached
+	Group        string     `json:"group"`         // empty unless grouped
+}
+
+func newWorktreeJSON(e workspace.Entry) worktreeJSON {
+	out := worktreeJSON{
+		Repo:        e.RepoName,
+		RepoPath:    e.RepoPath,
+		Path:        e.Path,
+		Branch:      e.Branch,
+		Slug:        e.Slug,
+		SessionName: e.SessionName,
+		IsRoot:      e.IsRoot,
+		HasSession:  e.HasSession(),
+		Dirty:       e.IsDirty(),
+	}
+	if e.Session != nil {
+		out.Windows = e.Session.Windows
+		out.Attached = e.Session.Attached
+	}
+	if e.Status != nil {
+		out.Status = &statusJSON{
+			Modified:  e.Status.Modified,
+			Added:     e.Status.Added,
+			Deleted:   e.Status.Deleted,
+			Untracked: e.Status.Untracked,
+		}
+	}
+	out.RecentTime = utcTime(e.RecentTime)
+	return out
+}
+
+func newSessionJSON(s tmux.Session) sessionJSON {
+	return sessionJSON{
+		Name:     s.Name,
+		Windows:  s.Windows,
+		Attached: s.Attached,
+		Workdir:  s.Workdir,
+		Path:     s.Path,
+
+		Created:      utcTime(s.Created),
+		Activity:     utcTime(s.Activity),
+		LastAttached: utcTime(s.LastAttached),
+		Group:        s.Group,
+	}
+}
+
+// utcTime returns t in UTC, or nil for the zero time.
+func utcTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// outputFormat is the listing format selected by --json / --ndjson.
+type outputFormat int
+
+const (
+	formatTable outputFormat = iota
+	formatJSON
+	formatNDJSON
+)
+
+// formatFlags registers --json and --ndjson and returns a function that
+// resolves them after parsing.
+func formatFlags(fs *flag.FlagSet) func() (outputFormat, error) {
+	asJSON := fs.Bool("json", false, "print a JSON document")
+	asNDJSON := fs.Bool("ndjson", false, "print one JSON object per line as results arrive")
+	return func() (outputFormat, error) {
+		switch {
+		case *asJSON && *asNDJSON:
+			return formatTable, usageErrorf("--json and --ndjson are mutually exclusive")
+		case *asJSON:
+			return formatJSON, nil
+		case *asNDJSON:
+			return formatNDJSON, nil
+		}
+		return formatTable, nil
+	}
+}
+
+// writeJSON writes v as an indented JSON document.
+func writeJSON(v any) error {
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func TestWorktreeJSON_Fields(t *testing.T) {
+	entry := workspace.Entry{
+		RepoPath:    "/src/api",
+		RepoName:    "api",
+		Path:        "/src/api/.worktrees/auth",
+		Branch:      "task/auth",
+		Slug:        "auth",
+		SessionName: "api_auth",
+		Session:     &tmux.Session{Name: "api_auth", Windows: 3, Attached: true},
+		Status:      &git.GitStatus{Modified: 1, Untracked: 2},
+		RecentTime:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	data, err := json.Marshal(newWorktreeJSON(entry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Consumers depend on these names; renaming one requires a SchemaVersion bump
+	expected := map[string]any{
+		"repo":         "api",
+		"repo_path":    "/src/api",
+		"path":         "/src/api/.worktrees/auth",
+		"branch":       "task/auth",
+		"slug":         "auth",
+		"session_name": "api_auth",
+		"is_root":      false,
+		"has_session":  true,
+		"windows":      float64(3),
+		"attached":     true,
+		"dirty":        true,
+		"recent_time":  "2025-01-02T03:04:05Z",
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("%s = %v, expected %v", key, got[key], want)
+		}
+	}
+
+	if _, ok := got["schema_version"]; ok {
+		t.Error("schema_version should only be set on NDJSON lines")
+	}
+
+	status, ok := got["status"].(map[string]any)
+	if !ok || status["modified"] != float64(1) || status["untracked"] != float64(2) {
+		t.Errorf("unexpected status: %v", got["status"])
+	}
+}
+
+func TestWorktreeJSON_NullsWithoutDetails(t *testing.T) {
+	data, err := json.Marshal(newWorktreeJSON(workspace.Entry{Path: "/src/api"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"status", "recent_time", "ports"} {
+		if v, ok := got[key]; !ok || v != nil {
+			t.Errorf("%s = %v, expected null", key, v)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+)
+
+func runPick(args []string) error {
+	fs := newFlagSet("pick")
+	printWhat := fs.String("print", "path", "what to print for the selection: path, session or json")
+	options := pickerFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	switch *printWhat {
+	case "path", "session", "json":
+	default:
+		return usageErrorf("--print must be path, session or json")
+	}
+
+	out, closeTTY := openTTY()
+	selection, err := runPicker(out, opts)
+	closeTTY()
+	if err != nil {
+		return err
+	}
+	if selection == nil {
+		return fmt.Errorf("nothing selected")
+	}
+
+	switch *printWhat {
+	case "session":
+		_, err = fmt.Fprintln(stdout, selection.SessionName)
+	case "json":
+		err = json.NewEncoder(stdout).Encode(selectionJSON(selection))
+	default:
+		_, err = fmt.Fprintln(stdout, selection.Cwd)
+	}
+	return err
+}
+
+// selectionJSON describes a picked item in the `twt list --ndjson` format.
+func selectionJSON(a *ui.AttachAction) worktreeJSON {
+	item := newWorktreeJSON(a.Entry)
+	item.SchemaVersion = SchemaVersion
+	item.SessionName = a.SessionName
+	item.Path = a.Cwd
+	return item
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+	"github.com/kargnas/tmux-worktree-tui/pkg/shell"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func runPopup(args []string) error {
+	fs := newFlagSet("popup")
+	client := fs.String("client", "", "tmux client to show the popup on (default: current client)")
+	width := fs.String("width", "80%", "popup width, in cells or percent of the client")
+	height := fs.String("height", "80%", "popup height, in cells or percent of the client")
+	inside := fs.Bool("inside", false, "run the picker inside an already open popup")
+	options := pickerFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	if !tmux.IsInsideTmux() && *client == "" {
+		return usageErrorf("popup must be run inside tmux or with --client")
+	}
+
+	if *inside {
+		return runPopupPicker(*client, opts)
+	}
+
+	if *client == "" {
+		if *client, err = tmux.CurrentClient(); err != nil {
+			return fmt.Errorf("cannot determine tmux client: %w", err)
+		}
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	// exec keeps the popup from leaving a shell behind once the picker exits
+	command := fmt.Sprintf("exec %s popup --inside --client %s", shell.Quote(self), shell.Quote(*client))
+	for _, arg := range forwardPickerFlags(fs) {
+		command += " " + shell.Quote(arg)
+	}
+	return tmux.DisplayPopup(*client, *width, *height, command)
+}
+
+// runPopupPicker runs the picker in the popup and switches the client that
+// opened it. Quitting just exits, which closes the popup.
+func runPopupPicker(client string, opts ui.Options) error {
+	selection, err := runPicker(os.Stdout, opts)
+	if err != nil || selection == nil {
+		return err
+	}
+
+	if err := ensureSession(selection); err != nil {
+		return err
+	}
+	return switchClient(selection, client)
+}
+
+func runInstallTmuxBinding(args []string) error {
+	fs := newFlagSet("install-tmux-binding")
+	key := fs.String("key", "T", "key to bind after the tmux prefix")
+	appendTo := fs.Bool("append", false, "append the binding to the tmux config instead of printing it")
+	file := fs.String("file", "~/.tmux.conf", "tmux config file used with --append")
+	options := pickerFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if _, err := options(); err != nil {
+		return err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	line := tmuxBindingLine(*key, self, forwardPickerFlags(fs))
+	if !*appendTo {
+		_, err := fmt.Fprintln(stdout, line)
+		return err
+	}
+
+	path := *file
+	if strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(home, path[2:])
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if strings.Contains(string(existing), line) {
+		fmt.Fprintf(stdout, "%s already contains the binding\n", path)
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	prefix := ""
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		prefix = "\n"
+	}
+	if _, err := fmt.Fprintf(f, "%s# twt picker popup\n%s\n", prefix, line); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "added to %s; reload with: tmux source-file %s\n", path, path)
+	return nil
+}
+
+// tmuxBindingLine builds the bind-key line. run-shell expands
+// #{client_name}, so the popup opens on the client that pressed the key.
+// pickerArgs select the initial view, so different keys can open different views.
+func tmuxBindingLine(key, self string, pickerArgs []string) string {
+	command := fmt.Sprintf("%s popup --client '#{client_name}'", shell.Quote(self))
+	for _, arg := range pickerArgs {
+		command += " " + shell.Quote(arg)
+	}
+	return fmt.Sprintf("bind-key %s run-shell -b %s", key, tmuxQuote(command))
+}
+
+// tmuxQuote quotes s as a single tmux config argument.
+func tmuxQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(s) + `"`
+}
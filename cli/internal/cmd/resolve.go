@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// resolveEntry finds the worktree a user refers to by session name or slug.
+// An exact session name wins; a slug is looked up in the current repository
+// first and then across all repositories, where it must be unambiguous.
+func resolveEntry(entries []workspace.Entry, target string) (*workspace.Entry, error) {
+	for i := range entries {
+		if entries[i].SessionName == target {
+			return &entries[i], nil
+		}
+	}
+
+	var matches []*workspace.Entry
+	for i := range entries {
+		if entries[i].Slug == target {
+			matches = append(matches, &entries[i])
+		}
+	}
+
+	if len(matches) > 1 {
+		if cwd, err := os.Getwd(); err == nil {
+			if root, err := git.GetMainRepoRoot(cwd); err == nil {
+				for _, e := range matches {
+					if samePath(e.RepoPath, root) {
+						return e, nil
+					}
+				}
+			}
+		}
+
+		var names []string
+		for _, e := range matches {
+			names = append(names, e.SessionName)
+		}
+		return nil, usageErrorf("%q is ambiguous: %s", target, strings.Join(names, ", "))
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	return nil, notFoundErrorf("no worktree or session matches %q", target)
+}
+
+// resolveRepo returns the main repository root for --repo, which may be a
+// path or the name of a discovered repository. An empty value means the
+// repository containing the working directory.
+func resolveRepo(repo string) (string, error) {
+	if repo == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		return git.GetMainRepoRoot(cwd)
+	}
+
+	if info, err := os.Stat(repo); err == nil && info.IsDir() {
+		return git.GetMainRepoRoot(repo)
+	}
+
+	cfg := workspace.LoadConfig()
+	for _, e := range workspace.Load(cfg) {
+		if e.RepoName == repo {
+			return e.RepoPath, nil
+		}
+	}
+	return "", notFoundErrorf("repository %q not found in search paths", repo)
+}
+
+func samePath(a, b string) bool {
+	ra, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		ra = a
+	}
+	rb, err := filepath.EvalSymlinks(b)
+	if err != nil {
+		rb = b
+	}
+	return filepath.Clean(ra) == filepath.Clean(rb)
+}
+
+// sessionNameFor returns the session name of slug in repoRoot.
+func sessionNameFor(repoRoot, slug string) string {
+	return naming.GetSessionName(naming.GetRepoName(repoRoot), slug)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func runRm(args []string) error {
+	fs := newFlagSet("rm")
+	repo := fs.String("repo", "", "repository of the task (name or path, default: current)")
+	force := fs.Bool("force", false, "remove even with uncommitted changes or unmerged commits")
+	yes := fs.Bool("yes", false, "do not ask for confirmation")
+	deleteBranch := fs.Bool("delete-branch", false, "also delete the task/* branch")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("rm requires exactly one <slug>")
+	}
+
+	repoRoot, err := resolveRepo(*repo)
+	if err != nil {
+		return err
+	}
+
+	entry, err := resolveEntry(workspace.LoadRepo(repoRoot), positional[0])
+	if err != nil {
+		return err
+	}
+	if entry.IsRoot {
+		return usageErrorf("refusing to remove the main worktree of %s", entry.RepoName)
+	}
+
+	check := task.Check(*entry)
+	if !check.Safe() && !*force {
+		for _, p := range check.Problems() {
+			fmt.Fprintf(stderr, "twt: %s: %s\n", entry.SessionName, p)
+		}
+		if *yes || !isInteractive() {
+			return fmt.Errorf("refusing to remove %s (use --force)", entry.Path)
+		}
+		ok, err := confirm(fmt.Sprintf("Remove %s and lose these changes?", entry.Path))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+		*force = true
+	} else if !*yes && isInteractive() {
+		ok, err := confirm(fmt.Sprintf("Remove %s?", entry.Path))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("aborted")
+		}
+	}
+
+	opts := task.RemoveOptions{Force: *force, DeleteBranch: *deleteBranch}
+	if err := task.Remove(*entry, opts); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "removed %s\n", entry.Path)
+	if *deleteBranch && strings.HasPrefix(entry.Branch, "task/") {
+		fmt.Fprintf(stdout, "deleted branch %s\n", entry.Branch)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK       = 0
+	ExitError    = 1
+	ExitUsage    = 2
+	ExitNotFound = 3
+)
+
+// Output streams. Commands write results to stdout and diagnostics to stderr.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+type command struct {
+	name   string
+	usage  string
+	short  string
+	run    func(args []string) error
+	args   argKind // what the positional argument completes to
+	hidden bool    // omitted from help and completion
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "list", usage: "list", short: "List worktrees of all discovered repositories", run: runList},
+		{name: "sessions", usage: "sessions", short: "List tmux sessions", run: runSessions},
+		{name: "attach", usage: "attach [-d|-r|--grouped] <session|slug>", short: "Attach or switch to a worktree session", run: runAttach, args: argTarget},
+		{name: "open", usage: "open <session|slug>", short: "Open a worktree in your editor", run: runOpen, args: argTarget},
+		{name: "run", usage: "run <session|slug> -- <cmd>", short: "Run a command in a worktree's session", run: runRun, args: argTarget},
+		{name: "pick", usage: "pick [--print=path|session|json]", short: "Run the picker and print the selection instead of attaching", run: runPick},
+		{name: "popup", usage: "popup", short: "Open the picker in a tmux popup", run: runPopup},
+		{name: "install-tmux-binding", usage: "install-tmux-binding", short: "Print or append a tmux key binding for the popup", run: runInstallTmuxBinding},
+		{name: "new", usage: "new <slug>", short: "Create a task worktree and its session", run: runNew},
+		{name: "rm", usage: "rm <slug>", short: "Kill a task session and remove its worktree", run: runRm, args: argTarget},
+		{name: "cleanup", usage: "cleanup", short: "Kill orphan sessions and remove orphan worktrees", run: runCleanup},
+		{name: "snapshot", usage: "snapshot [--every <interval>]", short: "Save the windows and panes of worktree sessions", run: runSnapshot},
+		{name: "restore", usage: "restore [--dry-run]", short: "Recreate sessions from the last snapshot", run: runRestore},
+		{name: "doctor", usage: "doctor", short: "Diagnose the environment and configuration", run: runDoctor},
+		{name: "completion", usage: "completion <bash|zsh|fish>", short: "Print a shell completion script", run: runCompletion, args: argShell},
+		{name: "__complete", run: runComplete, hidden: true},
+		{name: "help", usage: "help", short: "Show this help", run: runHelp},
+	}
+}
+
+// Run executes the command named by args[0], or the TUI when args is empty,
+// and returns the process exit code.
+func Run(args []string) int {
+	if len(args) == 0 {
+		return exitCode(runTUI(nil))
+	}
+
+	name := args[0]
+	if name == "-h" || name == "--help" {
+		name = "help"
+	} else if strings.HasPrefix(name, "-") {
+		// Flags without a command set up the TUI
+		return exitCode(runTUI(args))
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			return exitCode(c.run(args[1:]))
+		}
+	}
+
+	fmt.Fprintf(stderr, "twt: unknown command %q\n\n", name)
+	printUsage(stderr)
+	return ExitUsage
+}
+
+func runHelp(args []string) error {
+	printUsage(stdout)
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: twt [picker flags] | twt <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, twt starts the interactive picker. Picker flags:")
+	fmt.Fprintln(w, "  --tab projects|sessions  --sort name|recent|active  --dirty  --query <text>  --repo <name>")
+	fmt.Fprintln(w, "  -d (detach other clients)  -r (read-only)  --grouped (own current window)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Every command accepts --socket <name|path> and --profile <name> to pick the tmux server.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		if !c.hidden {
+			fmt.Fprintf(w, "  %-28s %s\n", c.usage, c.short)
+		}
+	}
+}
+
+// exitError carries a specific exit code up to Run.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func usageErrorf(format string, a ...any) error {
+	return &exitError{code: ExitUsage, err: fmt.Errorf(format, a...)}
+}
+
+func notFoundErrorf(format string, a ...any) error {
+	return &exitError{code: ExitNotFound, err: fmt.Errorf(format, a...)}
+}
+
+// exitCode reports err on stderr and maps it to an exit code.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	fmt.Fprintf(stderr, "twt: %v\n", err)
+
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return ExitError
+}
+
+// newFlagSet creates a flag set whose usage line matches the command table.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		for _, c := range commands {
+			if c.name == name {
+				fmt.Fprintf(stderr, "Usage: twt %s\n", c.usage)
+			}
+		}
+		fs.PrintDefaults()
+	}
+	addServerFlags(fs)
+	return fs
+}
+
+// parseFlags parses args allowing flags and positional arguments to be
+// interleaved. Everything after "--" is returned as positional arguments.
+// The tmux server is selected once the flags are known.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional, err := splitFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	return positional, selectServer()
+}
+
+func splitFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &exitError{code: ExitUsage, err: err}
+		}
+
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		// flag.Parse stops at "--" or the first non-flag argument
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if strings.HasPrefix(rest[0], "-") && rest[0] != "-" {
+			args = rest
+			continue
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
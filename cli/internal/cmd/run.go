@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/shell"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// runPollInterval is how often --wait checks whether the command finished.
+const runPollInterval = 200 * time.Millisecond
+
+// runStatusPolls bounds how often --wait asks again for the exit status of a
+// dead pane before giving up on it; see tmux.ErrNoExitStatus.
+const runStatusPolls = 10
+
+func runRun(args []string) error {
+	fs := newFlagSet("run")
+	window := fs.String("window", "", "window to run in, created if missing (default: the session's current window)")
+	wait := fs.Bool("wait", false, "wait for the command to finish, print its output and exit with its status")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 {
+		return usageErrorf("run requires a <slug|session> and a command after --")
+	}
+	target := positional[0]
+	command := commandLine(positional[1:])
+
+	sessionName, cwd, err := resolveRunTarget(target)
+	if err != nil {
+		return err
+	}
+
+	if !tmux.HasSession(sessionName) {
+		if err := layout.CreateSessionFor(sessionName, cwd); err != nil {
+			return err
+		}
+	}
+
+	if *wait {
+		return runAndWait(sessionName, *window, cwd, command)
+	}
+
+	pane := "=" + sessionName + ":"
+	if *window != "" {
+		if id, ok := tmux.FindWindow(sessionName, *window); ok {
+			pane = id
+		} else if pane, err = tmux.NewWindow(sessionName, *window, cwd, ""); err != nil {
+			return err
+		}
+	}
+	return tmux.SendKeys(pane, command)
+}
+
+// resolveRunTarget maps a slug or session name to a session and the
+// directory new windows start in. Unmanaged sessions work by exact name.
+func resolveRunTarget(target string) (string, string, error) {
+	entry, err := resolveEntry(workspace.Load(workspace.LoadConfig()), target)
+	if err != nil {
+		var ee *exitError
+		if errors.As(err, &ee) && ee.code == ExitNotFound && tmux.HasSession(target) {
+			workdir, _ := tmux.GetSessionWorkdir(target)
+			return target, workdir, nil
+		}
+		return "", "", err
+	}
+	return entry.SessionName, entry.Path, nil
+}
+
+// runAndWait runs command as the process of a new pane so its exit status
+// can be read from pane_dead_status. The pane waits on a tmux channel until
+// remain-on-exit is set, so even an instant exit is not missed.
+func runAndWait(sessionName, window, cwd, command string) error {
+	channel := fmt.Sprintf("twt-run-%d", os.Getpid())
+	paneCommand := fmt.Sprintf("tmux wait-for %s; %s", channel, command)
+
+	var pane string
+	var err error
+	if id, ok := tmux.FindWindow(sessionName, window); window != "" && ok {
+		pane, err = tmux.SplitWindow(id, tmux.Split{Cwd: cwd, Command: paneCommand})
+	} else {
+		pane, err = tmux.NewWindow(sessionName, window, cwd, paneCommand)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tmux.SetPaneOption(pane, "remain-on-exit", "on"); err != nil {
+		_ = tmux.KillPane(pane)
+		return fmt.Errorf("failed to set remain-on-exit: %w", err)
+	}
+	if err := tmux.SignalChannel(channel); err != nil {
+		_ = tmux.KillPane(pane)
+		return err
+	}
+
+	unknown := 0
+	for {
+		dead, status, err := tmux.PaneExitStatus(pane)
+		if errors.Is(err, tmux.ErrNoExitStatus) && unknown < runStatusPolls {
+			unknown++
+			dead = false
+		} else if err != nil && !errors.Is(err, tmux.ErrNoExitStatus) {
+			return fmt.Errorf("lost track of pane %s: %w", pane, err)
+		}
+		if !dead {
+			time.Sleep(runPollInterval)
+			continue
+		}
+
+		output, _ := tmux.CapturePane(pane)
+		fmt.Fprint(stdout, paneOutput(output))
+		_ = tmux.KillPane(pane)
+
+		if err != nil {
+			return errors.New("command exited without a status, probably killed by a signal")
+		}
+		// The command's own status is passed on, so 2 and 3 may come from
+		// the command rather than mean ExitUsage or ExitNotFound
+		if status != 0 {
+			return &exitError{code: status, err: fmt.Errorf("command exited with status %d", status)}
+		}
+		return nil
+	}
+}
+
+// paneOutput drops the trailing blank lines and the "Pane is dead" notice
+// tmux draws into a pane kept by remain-on-exit.
+func paneOutput(captured string) string {
+	lines := strings.Split(strings.TrimRight(captured, "\n"), "\n")
+	for len(lines) > 0 {
+		last := strings.TrimSpace(lines[len(lines)-1])
+		if last != "" && !strings.HasPrefix(last, "Pane is dead") {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// commandLine joins argv into a shell command. A single argument is taken
+// as a complete shell command, so `twt run x -- "npm run dev"` works too.
+func commandLine(argv []string) string {
+	if len(argv) == 1 {
+		return argv[0]
+	}
+	return shell.Join(argv)
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"flag"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// serverFlags holds --socket and --profile, which every command accepts.
+var serverFlags struct {
+	socket  string
+	profile string
+}
+
+func addServerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&serverFlags.socket, "socket", "", "tmux server: a socket name as for tmux -L, or a path as for tmux -S")
+	fs.StringVar(&serverFlags.profile, "profile", "", "config profile to take the tmux socket from")
+}
+
+// selectServer points pkg/tmux at the server chosen by --socket, then the
+// config profile, then $TMUX. Without any of them tmux's default server
+// is used.
+func selectServer() error {
+	socket := serverFlags.socket
+	if socket == "" {
+		var err error
+		if socket, err = workspace.LoadConfig().ProfileSocket(serverFlags.profile); err != nil {
+			return usageErrorf("%v", err)
+		}
+	}
+
+	if socket != "" {
+		tmux.Server = tmux.ParseSocket(discovery.ExpandPath(socket))
+	} else {
+		tmux.Server = tmux.SocketFromEnv()
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func runSessions(args []string) error {
+	fs := newFlagSet("sessions")
+	format := formatFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	outFormat, err := format()
+	if err != nil {
+		return err
+	}
+
+	sessions, err := tmux.ListSessions()
+	if err != nil {
+		return err
+	}
+
+	switch outFormat {
+	case formatJSON:
+		items := []sessionJSON{}
+		for _, s := range sessions {
+			items = append(items, newSessionJSON(s))
+		}
+		return writeJSON(struct {
+			SchemaVersion int           `json:"schema_version"`
+			Sessions      []sessionJSON `json:"sessions"`
+		}{SchemaVersion, items})
+
+	case formatNDJSON:
+		enc := json.NewEncoder(stdout)
+		for _, s := range sessions {
+			item := newSessionJSON(s)
+			item.SchemaVersion = SchemaVersion
+			if err := enc.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tWINDOWS\tATTACHED\tWORKDIR")
+	for _, s := range sessions {
+		attached := "no"
+		if s.Attached {
+			attached = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", s.Name, s.Windows, attached, s.Workdir)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/snapshot"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// errNoSessions keeps an empty snapshot from replacing the file.
+var errNoSessions = errors.New("no worktree sessions to snapshot")
+
+func runSnapshot(args []string) error {
+	fs := newFlagSet("snapshot")
+	file := fs.String("file", "", "snapshot file (default: snapshot.json in the state directory)")
+	every := fs.Duration("every", 0, "keep running and take a snapshot at this interval, e.g. 15m")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *every < 0 {
+		return usageErrorf("--every must be positive")
+	}
+
+	path, err := snapshotPath(*file)
+	if err != nil {
+		return err
+	}
+
+	if *every == 0 {
+		n, err := takeSnapshot(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "saved %d session(s) to %s\n", n, path)
+		return nil
+	}
+
+	for {
+		if _, err := takeSnapshot(path); err != nil && !errors.Is(err, errNoSessions) {
+			fmt.Fprintf(stderr, "twt: %v\n", err)
+		}
+		time.Sleep(*every)
+	}
+}
+
+// takeSnapshot saves the sessions of all discovered worktrees to path and
+// returns how many there were. Sessions that the previous snapshot holds
+// and that died with an earlier server are kept until they are restored.
+func takeSnapshot(path string) (int, error) {
+	snap, err := snapshot.Take(workspace.Load(workspace.LoadConfig()))
+	if err != nil {
+		return 0, err
+	}
+	if prev, err := snapshot.Load(path); err == nil {
+		server, _ := tmux.ServerInstance()
+		snap.Carry(prev, server, tmux.HasSession)
+	}
+	if len(snap.Sessions) == 0 {
+		return 0, errNoSessions
+	}
+	return len(snap.Sessions), snap.Save(path)
+}
+
+func runRestore(args []string) error {
+	fs := newFlagSet("restore")
+	file := fs.String("file", "", "snapshot file (default: snapshot.json in the state directory)")
+	dryRun := fs.Bool("dry-run", false, "only report what would be restored")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := snapshotPath(*file)
+	if err != nil {
+		return err
+	}
+	snap, err := snapshot.Load(path)
+	if os.IsNotExist(err) {
+		return notFoundErrorf("no snapshot at %s (run twt snapshot first)", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg := workspace.LoadConfig()
+	run := cfg.RestoreCommands
+	if run == nil {
+		run = snapshot.DefaultRunCommands
+	}
+
+	var failed int
+	for _, s := range snap.Sessions {
+		switch {
+		case tmux.HasSession(s.Name):
+			fmt.Fprintf(stdout, "skipped %s: already running\n", s.Name)
+			continue
+		case !dirExists(s.Workdir):
+			fmt.Fprintf(stderr, "twt: skipping %s: %s is gone\n", s.Name, s.Workdir)
+			continue
+		case *dryRun:
+			fmt.Fprintf(stdout, "would restore %s (%d windows)\n", s.Name, len(s.Windows))
+			continue
+		}
+
+		env, err := layout.Env(cfg, layout.WorktreeAt(s.Workdir))
+		if err == nil {
+			err = snapshot.Restore(s, snapshot.RestoreOptions{Env: env, Run: run})
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "twt: %s: %v\n", s.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "restored %s (%d windows)\n", s.Name, len(s.Windows))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d session(s) could not be restored", failed)
+	}
+	return nil
+}
+
+// snapshotPath returns file, or the default snapshot file if it is empty.
+func snapshotPath(file string) (string, error) {
+	if file != "" {
+		return file, nil
+	}
+	return snapshot.GetPath()
+}
+
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
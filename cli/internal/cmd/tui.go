@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"syscall"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/kargnas/tmux-worktree-tui/internal/ui"
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/mattn/go-isatty"
+)
+
+// runTUI starts the interactive picker and attaches to the selection.
+func runTUI(args []string) error {
+	fs := newFlagSet("twt")
+	options := pickerFlags(fs)
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	opts, err := options()
+	if err != nil {
+		return err
+	}
+
+	selection, err := runPicker(os.Stdout, opts)
+	if err != nil || selection == nil {
+		return err
+	}
+	return attachSession(selection)
+}
+
+// runPicker runs the TUI, drawing to out, and returns the selected item or
+// nil if the user quit without choosing.
+func runPicker(out io.Writer, opts ui.Options) (*ui.AttachAction, error) {
+	model := ui.NewModel(opts)
+
+	programOpts := []tea.ProgramOption{tea.WithOutput(out)}
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	p := tea.NewProgram(model, programOpts...)
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, fmt.Errorf("alas, there's been an error: %w", err)
+	}
+
+	m, ok := finalModel.(ui.Model)
+	if !ok {
+		return nil, nil
+	}
+	m.Close()
+	return m.AttachSession, nil
+}
+
+// openTTY returns a writer for drawing the TUI when stdout is being captured:
+// the controlling terminal if there is one, stderr otherwise. Styles are
+// switched to the chosen writer's color profile, since lipgloss detects it
+// from stdout by default.
+func openTTY() (io.Writer, func()) {
+	var out io.Writer = os.Stderr
+	closeFn := func() {}
+
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		out = tty
+		closeFn = func() { tty.Close() }
+	}
+
+	renderer := lipgloss.NewRenderer(out)
+	lipgloss.SetColorProfile(renderer.ColorProfile())
+	lipgloss.SetHasDarkBackground(renderer.HasDarkBackground())
+	return out, closeFn
+}
+
+// attachSession creates the session if needed and then attaches to the
+// selection as its mode says. Inside tmux the current client is switched;
+// outside tmux the process is replaced by `tmux attach` so the terminal is
+// handed over cleanly.
+func attachSession(a *ui.AttachAction) error {
+	if err := ensureSession(a); err != nil {
+		return err
+	}
+
+	if tmux.IsCurrentServer() {
+		return switchClient(a, "")
+	}
+
+	tmuxPath, err := exec.LookPath("tmux")
+	if err != nil {
+		return fmt.Errorf("error finding tmux: %w", err)
+	}
+
+	// Inside a pane of another server, tmux refuses to nest a client
+	// unless $TMUX is unset
+	env := os.Environ()
+	if tmux.IsInsideTmux() {
+		env = slices.DeleteFunc(env, func(kv string) bool { return strings.HasPrefix(kv, "TMUX=") })
+	}
+
+	if a.Mode == tmux.AttachGrouped {
+		if a, err = groupedSelection(a); err != nil {
+			return err
+		}
+	}
+
+	// syscall.Exec replaces the current process entirely
+	// This ensures proper terminal handling for tmux
+	argv := append([]string{"tmux"}, tmux.Server.Args()...)
+	argv = append(argv, "attach")
+	argv = append(argv, a.Mode.AttachFlags()...)
+	argv = append(argv, "-t", a.Target())
+	if a.Mode == tmux.AttachGrouped {
+		argv = append(argv, ";")
+		argv = append(argv, tmux.DestroyUnattachedArgs(a.SessionName)...)
+	}
+	err = syscall.Exec(tmuxPath, argv, env)
+	if err != nil {
+		if a.Mode == tmux.AttachGrouped {
+			tmux.KillSession(a.SessionName)
+		}
+		return fmt.Errorf("error attaching to session: %w", err)
+	}
+	return nil
+}
+
+// ensureSession creates the selected session if it does not exist yet.
+func ensureSession(a *ui.AttachAction) error {
+	if tmux.HasSession(a.SessionName) {
+		return nil
+	}
+	return layout.CreateSessionFor(a.SessionName, a.Cwd)
+}
+
+// switchClient switches client, or the current client when empty, to the
+// selection as its mode says.
+func switchClient(a *ui.AttachAction, client string) error {
+	if a.Mode == tmux.AttachReadOnly {
+		// Switching would make this very client read-only, with no way back
+		return fmt.Errorf("read-only attach needs a terminal of its own; run it outside tmux")
+	}
+
+	var err error
+	if a.Mode == tmux.AttachDetachOthers && client == "" {
+		if client, err = tmux.CurrentClient(); err != nil {
+			return fmt.Errorf("cannot determine tmux client: %w", err)
+		}
+	}
+
+	if a.Mode == tmux.AttachGrouped {
+		if a, err = groupedSelection(a); err != nil {
+			return err
+		}
+	}
+
+	if client == "" {
+		err = tmux.SwitchClient(a.Target())
+	} else {
+		err = tmux.SwitchClientOf(client, a.Target())
+	}
+	if err != nil {
+		if a.Mode == tmux.AttachGrouped {
+			tmux.KillSession(a.SessionName)
+		}
+		return fmt.Errorf("error switching to session: %w", err)
+	}
+
+	switch a.Mode {
+	case tmux.AttachDetachOthers:
+		return tmux.DetachOthers(a.SessionName, client)
+	case tmux.AttachGrouped:
+		return tmux.SetDestroyUnattached(a.SessionName)
+	}
+	return nil
+}
+
+// groupedSelection returns a copy of a that targets a new session grouped
+// with the selected one.
+func groupedSelection(a *ui.AttachAction) (*ui.AttachAction, error) {
+	name, err := tmux.NewGroupedSession(a.SessionName, a.Cwd)
+	if err != nil {
+		return nil, err
+	}
+	grouped := *a
+	grouped.SessionName = name
+	return &grouped, nil
+}
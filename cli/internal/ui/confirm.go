@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// confirmation is a question shown in the status bar. Each key in actions
+// answers it by running the associated command; any cancel key dismisses it.
+type confirmation struct {
+	question string
+	actions  map[string]confirmAction
+}
+
+type confirmAction struct {
+	label string
+	cmd   tea.Cmd
+}
+
+func (c confirmation) prompt() string {
+	keys := make([]string, 0, len(c.actions))
+	for k := range c.actions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var choices []string
+	for _, k := range keys {
+		choices = append(choices, k+": "+c.actions[k].label)
+	}
+	choices = append(choices, "n: Cancel")
+	return c.question + " " + strings.Join(choices, " • ")
+}
+
+// updateConfirm handles keys while a confirmation is waiting for an answer.
+func (m Model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.confirm
+
+	if action, ok := c.actions[msg.String()]; ok {
+		m.confirm = nil
+		return m, action.cmd
+	}
+
+	switch msg.String() {
+	case "n", "esc", "q", "ctrl+c":
+		m.confirm = nil
+	}
+	return m, nil
+}
@@ -42,6 +42,11 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		baseStyle = itemStyle
 	}
 
+	if i.Type == ItemTypePane {
+		fmt.Fprint(w, baseStyle.Render(renderPane(i)))
+		return
+	}
+
 	// 2. Prepare Content
 	// Icon
 	var icon string
@@ -81,6 +86,9 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	if i.IsDirty {
 		statusBadge = statusDirtyStyle.Render("● Modified")
 	}
+	if i.Orphan != nil {
+		statusBadge += orphanStyle.Render("⚠ Orphan (" + i.Orphan.Reason() + ")")
+	}
 
 	// Construct Line 1
 	// [Icon] [Title]  [Info]        [Status]
@@ -102,3 +110,15 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	// Apply selection box style
 	fmt.Fprint(w, baseStyle.Render(content))
 }
+
+// renderPane renders a pane of an expanded session, indented below it:
+// window and pane index, window name, running command and directory.
+func renderPane(i Item) string {
+	info := i.DescStr
+	if i.Pane.WindowActive && i.Pane.Active {
+		info += " • Active"
+	}
+	line1 := fmt.Sprintf("  └ %s  %s", repoNameStyle.Render(i.TitleStr), statusStyle.Render(info))
+	path := "    " + pathStyle.Render(i.Path)
+	return lipgloss.JoinVertical(lipgloss.Left, line1, path)
+}
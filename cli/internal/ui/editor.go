@@ -0,0 +1,24 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/editor"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+type editorClosedMsg struct {
+	path string
+	err  error
+}
+
+// openEditorCmd hands the terminal to the item's editor and resumes the
+// picker when it exits. GUI editors return right away.
+func openEditorCmd(i Item) tea.Cmd {
+	cmd, err := editor.Command(workspace.LoadConfig(), i.Entry.RepoName, i.Entry.RepoPath, i.Path)
+	if err != nil {
+		return func() tea.Msg { return editorClosedMsg{path: i.Path, err: err} }
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorClosedMsg{path: i.Path, err: err}
+	})
+}
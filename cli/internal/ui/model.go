@@ -11,12 +11,10 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/kargnas/tmux-worktree-tui/pkg/config"
 	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
-	"github.com/kargnas/tmux-worktree-tui/pkg/git"
-	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
-	"github.com/kargnas/tmux-worktree-tui/pkg/recent"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
 	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
 )
 
 // ItemType distinguishes between git repos and tmux sessions
@@ -25,6 +23,7 @@ type ItemType int
 const (
 	ItemTypeRepo ItemType = iota
 	ItemTypeSession
+	ItemTypePane // a pane of an expanded session in the Sessions tab
 )
 
 // Item represents a list item (Project or Session)
@@ -39,16 +38,37 @@ type Item struct {
 	HasSession  bool
 	RecentTime  time.Time
 	Type        ItemType
+	Entry       workspace.Entry // Source worktree, used by actions
+	Orphan      *task.Orphan    // Set when the session or worktree has lost its counterpart
+	Pane        *tmux.Pane      // Set for ItemTypePane
 }
 
 func (i Item) Title() string       { return i.TitleStr }
 func (i Item) Description() string { return i.DescStr }
-func (i Item) FilterValue() string { return i.TitleStr + " " + i.DescStr }
+
+func (i Item) FilterValue() string {
+	if i.Pane != nil {
+		// Panes stay visible when filtering for their session
+		return i.SessionName + " " + i.TitleStr + " " + i.DescStr
+	}
+	return i.TitleStr + " " + i.DescStr
+}
 
 // AttachAction is the result returned to main.go
 type AttachAction struct {
 	SessionName string
 	Cwd         string
+	Entry       workspace.Entry // Worktree behind the selection; empty for orphan sessions
+	Pane        *tmux.Pane      // Window and pane to land on; nil for the session's current one
+	Mode        tmux.AttachMode
+}
+
+// Target returns the tmux target to attach or switch to.
+func (a AttachAction) Target() string {
+	if a.Pane != nil {
+		return tmux.PaneTarget(a.SessionName, a.Pane.ID)
+	}
+	return "=" + a.SessionName
 }
 
 type Tab int
@@ -66,6 +86,40 @@ const (
 	SortByActive
 )
 
+// Options sets the initial state of the picker.
+type Options struct {
+	Tab       Tab
+	Sort      SortType
+	DirtyOnly bool
+	Query     string // Pre-filled list filter
+	Repo      string // Only show worktrees of this repository
+	Attach    tmux.AttachMode
+}
+
+// ParseTab parses a tab name as used by the --tab flag.
+func ParseTab(name string) (Tab, error) {
+	switch strings.ToLower(name) {
+	case "projects":
+		return TabProjects, nil
+	case "sessions":
+		return TabSessions, nil
+	}
+	return TabProjects, fmt.Errorf("unknown tab %q (projects, sessions)", name)
+}
+
+// ParseSort parses a sort order as used by the --sort flag.
+func ParseSort(name string) (SortType, error) {
+	switch strings.ToLower(name) {
+	case "name":
+		return SortByName, nil
+	case "recent":
+		return SortByRecent, nil
+	case "active":
+		return SortByActive, nil
+	}
+	return SortByName, fmt.Errorf("unknown sort %q (name, recent, active)", name)
+}
+
 type Model struct {
 	list        list.Model
 	tabs        []string
@@ -76,8 +130,26 @@ type Model struct {
 	loading     bool
 	spinner     spinner.Model
 	filterDirty bool
+	filterRepo  string
+	attachMode  tmux.AttachMode
+
+	// Pending confirmation or rename, and last action result
+	confirm *confirmation
+	rename  *renameInput
+	notice  string
+
+	// Live capture of the selected session
+	preview preview
+
+	// Sessions tracked through tmux control mode
+	watch watch
+
+	// Panes of the sessions expanded in the Sessions tab, by session name
+	expanded map[string][]tmux.Pane
 
 	// Data storage
+	entries     []workspace.Entry
+	sessions    []tmux.Session
 	allRepos    []Item
 	allSessions []Item
 
@@ -85,7 +157,7 @@ type Model struct {
 	AttachSession *AttachAction
 }
 
-func NewModel() Model {
+func NewModel(opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
@@ -98,15 +170,24 @@ func NewModel() Model {
 	l.SetShowTitle(false)
 	l.DisableQuitKeybindings()
 	l.Filter = fuzzyFilter
+	if opts.Query != "" {
+		// Applied again by SetItems once data has loaded
+		l.SetFilterText(opts.Query)
+	}
 
 	return Model{
 		list:        l,
 		tabs:        []string{"Projects", "Sessions"},
-		activeTab:   TabProjects,
+		activeTab:   opts.Tab,
+		sortType:    opts.Sort,
+		filterDirty: opts.DirtyOnly,
+		filterRepo:  opts.Repo,
+		attachMode:  opts.Attach,
 		spinner:     s,
 		loading:     true,
 		allRepos:    []Item{},
 		allSessions: []Item{},
+		expanded:    make(map[string][]tmux.Pane),
 	}
 }
 
@@ -114,9 +195,15 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
 		loadDataCmd(),
+		startControlCmd(),
 	)
 }
 
+// Close detaches the picker from tmux. Call it once the program has exited.
+func (m Model) Close() {
+	m.watch.Close()
+}
+
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
@@ -125,24 +212,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.resize()
 
-		// Update list size
-		// Calculate header height (approximate or measured)
-		// Header = Tabs (3) + Gap (1) = 4 lines?
-		// We'll measure precisely in View, but here we need to set list height.
-		// Let's assume a fixed header height for stability, or calculate it.
-		// Safe bet: Height - 6 (Header + Footer)
-		headerHeight := 3 // Tabs + borders
-		footerHeight := 2 // Status bar
-
-		listHeight := m.height - headerHeight - footerHeight
-		if listHeight < 0 {
-			listHeight = 0
+	case tea.KeyMsg:
+		if m.confirm != nil {
+			return m.updateConfirm(msg)
 		}
+		if m.rename != nil {
+			return m.updateRename(msg)
+		}
+		m.notice = ""
 
-		m.list.SetSize(msg.Width, listHeight)
-
-	case tea.KeyMsg:
 		if m.list.FilterState() == list.Filtering {
 			break // Let list handle keys when filtering
 		}
@@ -151,6 +231,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("q", "ctrl+c"))):
 			return m, tea.Quit
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+			// Esc clears an applied filter first, then quits
+			if m.list.FilterState() == list.Unfiltered {
+				return m, tea.Quit
+			}
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("tab"))):
 			m.switchTab()
 			cmds = append(cmds, m.refreshList())
@@ -163,21 +249,133 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.sortType = (m.sortType + 1) % 3
 			cmds = append(cmds, m.refreshList())
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("a"))):
+			m.attachMode = m.attachMode.Next()
+			m.notice = "Attach: " + m.attachMode.String()
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
 			if i, ok := m.list.SelectedItem().(Item); ok {
 				return m.selectItem(i)
 			}
 
+		case key.Matches(msg, key.NewBinding(key.WithKeys("e"))):
+			if i, ok := m.list.SelectedItem().(Item); ok {
+				if i.Path == "" {
+					m.notice = "No directory to open"
+				} else {
+					cmds = append(cmds, openEditorCmd(i))
+				}
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("K"))):
+			if i, ok := m.list.SelectedItem().(Item); ok {
+				if i.HasSession {
+					m.confirm = killConfirmation(i)
+				} else {
+					m.notice = "No session to kill"
+				}
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("R"))):
+			if i, ok := m.list.SelectedItem().(Item); ok {
+				if i.HasSession || isTaskItem(i) {
+					m.rename = newRenameInput(i)
+					return m, nil
+				}
+				m.notice = "No session to rename"
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("w"))):
+			cmds = append(cmds, m.toggleExpanded())
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("p"))):
+			cmds = append(cmds, m.togglePreview())
+
 		case key.Matches(msg, key.NewBinding(key.WithKeys("r"))):
 			m.loading = true
 			cmds = append(cmds, loadDataCmd())
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("o"))):
+			if i, ok := m.list.SelectedItem().(Item); ok && i.Orphan != nil {
+				var fix tea.Cmd
+				m.confirm, fix = orphanFix(i)
+				cmds = append(cmds, fix)
+			}
+
+		case key.Matches(msg, key.NewBinding(key.WithKeys("x"))):
+			if i, ok := m.list.SelectedItem().(Item); ok {
+				switch {
+				case i.Entry.Path == "":
+					m.notice = "No worktree to remove"
+				case i.Entry.IsRoot:
+					m.notice = "The main worktree cannot be removed"
+				default:
+					cmds = append(cmds, checkRemovalCmd(i))
+				}
+			}
+		}
+
+	case previewTickMsg:
+		if m.preview.on && msg.gen == m.preview.gen {
+			cmds = append(cmds, capturePreviewCmd(m.preview.target), previewTickCmd(msg.gen))
+		}
+
+	case previewMsg:
+		if msg.target == m.preview.target {
+			m.preview.content, m.preview.err = msg.content, msg.err
 		}
 
+	case controlStartedMsg, controlEventMsg, controlClosedMsg, controlRetryMsg, sessionRefreshedMsg:
+		cmds = append(cmds, m.updateWatch(msg))
+
+	case panesLoadedMsg:
+		cmds = append(cmds, m.updatePanes(msg))
+
+	case sessionsRefreshedMsg:
+		cmds = append(cmds, m.applySessions(msg.sessions))
+
+	case removalCheckedMsg:
+		m.confirm = removeConfirmation(msg)
+
+	case orphanFixedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Fix failed: %v", msg.err)
+		} else {
+			m.notice = msg.result
+		}
+		m.loading = true
+		cmds = append(cmds, loadDataCmd())
+
+	case editorClosedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Editor failed: %v", msg.err)
+		} else {
+			m.notice = "Opened " + msg.path
+		}
+
+	case sessionChangedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed: %v", msg.err)
+		} else {
+			m.notice = msg.result
+		}
+		m.loading = true
+		cmds = append(cmds, loadDataCmd())
+
+	case removedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Remove failed: %v", msg.err)
+		} else {
+			m.notice = "Removed " + msg.item.Entry.Path
+		}
+		m.loading = true
+		cmds = append(cmds, loadDataCmd())
+
 	case dataLoadedMsg:
 		m.loading = false
-		m.allRepos = msg.repos
-		m.allSessions = msg.sessions
-		cmds = append(cmds, m.refreshList())
+		m.entries = msg.entries
+		m.sessions = msg.sessions
+		cmds = append(cmds, m.rebuildItems(), m.watch.refresh())
 
 	case spinner.TickMsg:
 		m.spinner, cmd = m.spinner.Update(msg)
@@ -185,11 +383,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	m.list, cmd = m.list.Update(msg)
-	cmds = append(cmds, cmd)
+	cmds = append(cmds, cmd, m.syncPreview())
 
 	return m, tea.Batch(cmds...)
 }
 
+// resize fits the list into the space left by the header, the status bar
+// and the preview.
+func (m *Model) resize() {
+	// Update list size
+	// Calculate header height (approximate or measured)
+	// Header = Tabs (3) + Gap (1) = 4 lines?
+	// We'll measure precisely in View, but here we need to set list height.
+	// Let's assume a fixed header height for stability, or calculate it.
+	// Safe bet: Height - 6 (Header + Footer)
+	headerHeight := 3 // Tabs + borders
+	footerHeight := 2 // Status bar
+
+	listHeight := m.height - headerHeight - footerHeight
+	if listHeight < 0 {
+		listHeight = 0
+	}
+
+	m.list.SetSize(m.width-m.previewWidth(), listHeight)
+}
+
 func (m *Model) switchTab() {
 	if m.activeTab == TabProjects {
 		m.activeTab = TabSessions
@@ -211,6 +429,9 @@ func (m *Model) refreshList() tea.Cmd {
 		if m.filterDirty && !item.IsDirty {
 			continue
 		}
+		if m.filterRepo != "" && item.Entry.RepoName != m.filterRepo {
+			continue
+		}
 		filtered = append(filtered, item)
 	}
 
@@ -234,6 +455,11 @@ func (m *Model) refreshList() tea.Cmd {
 
 	for _, item := range filtered {
 		items = append(items, item)
+		if m.activeTab == TabSessions {
+			for _, pane := range paneItems(item, m.expanded[item.SessionName]) {
+				items = append(items, pane)
+			}
+		}
 	}
 
 	return m.list.SetItems(items)
@@ -244,6 +470,9 @@ func (m Model) selectItem(i Item) (tea.Model, tea.Cmd) {
 	m.AttachSession = &AttachAction{
 		SessionName: i.SessionName,
 		Cwd:         i.Path,
+		Entry:       i.Entry,
+		Pane:        i.Pane,
+		Mode:        m.attachMode,
 	}
 	return m, tea.Quit
 }
@@ -256,9 +485,18 @@ func (m Model) View() string {
 	header := m.viewHeader()
 	statusBar := m.viewStatusBar()
 
+	body := m.list.View()
+	if m.showPreview() {
+		listWidth := m.width - m.previewWidth()
+		body = lipgloss.JoinHorizontal(lipgloss.Top,
+			lipgloss.NewStyle().Width(listWidth).Render(body),
+			m.viewPreview(m.previewWidth(), m.list.Height()),
+		)
+	}
+
 	return lipgloss.JoinVertical(lipgloss.Left,
 		header,
-		m.list.View(),
+		body,
 		statusBar,
 	)
 }
@@ -281,6 +519,14 @@ func (m Model) viewHeader() string {
 		row = lipgloss.JoinHorizontal(lipgloss.Center, row, filterStyle.Render("F:Dirty Only"))
 	}
 
+	if m.filterRepo != "" {
+		row = lipgloss.JoinHorizontal(lipgloss.Center, row, filterStyle.Render("Repo:"+m.filterRepo))
+	}
+
+	if m.attachMode != tmux.AttachShared {
+		row = lipgloss.JoinHorizontal(lipgloss.Center, row, filterStyle.Render("Attach:"+m.attachMode.String()))
+	}
+
 	// Spinner
 	if m.loading {
 		row = lipgloss.JoinHorizontal(lipgloss.Center, row, "  ", m.spinner.View())
@@ -290,89 +536,124 @@ func (m Model) viewHeader() string {
 }
 
 func (m Model) viewStatusBar() string {
+	// Cut long lines rather than let them wrap and push the list up
+	style := statusBarStyle.MaxWidth(m.width)
+
+	if m.confirm != nil {
+		return style.Render(m.confirm.prompt())
+	}
+	if m.rename != nil {
+		return style.Render(m.rename.prompt())
+	}
+	if m.notice != "" {
+		return style.Render(m.notice)
+	}
+
 	sortLabel := []string{"Name", "Recent", "Active"}[m.sortType]
-	help := fmt.Sprintf("Tab: Switch • f: Filter • s: Sort(%s) • Enter: Select • r: Reload • q: Quit", sortLabel)
-	return statusBarStyle.Render(help)
+	help := fmt.Sprintf("Tab: Switch • f: Filter • s: Sort(%s) • Enter: Select • a: Attach mode • e: Editor • w: Windows • p: Preview • K: Kill • R: Rename • x: Remove • o: Fix orphan • r: Reload • q: Quit", sortLabel)
+	return style.Render(help)
 }
 
 // Data Loading
 
 type dataLoadedMsg struct {
-	repos    []Item
-	sessions []Item
+	entries  []workspace.Entry
+	sessions []tmux.Session
 }
 
 func loadDataCmd() tea.Cmd {
 	return func() tea.Msg {
-		cfg, err := config.LoadConfig()
-		if err != nil {
-			cfg = &config.Config{Depth: 2}
-		}
-
+		cfg := workspace.LoadConfig()
 		repos := discovery.FindGitRepos(cfg.SearchPaths, cfg.Depth)
 		tmuxSessions, _ := tmux.ListSessions()
-
-		sessionMap := make(map[string]tmux.Session)
-		for _, s := range tmuxSessions {
-			sessionMap[s.Name] = s
+		entries := workspace.Match(repos, tmuxSessions)
+		for i := range entries {
+			entries[i].LoadDetails()
 		}
 
-		var repoItems []Item
-		var sessionItems []Item
+		return dataLoadedMsg{
+			entries:  entries,
+			sessions: tmuxSessions,
+		}
+	}
+}
 
-		for _, repoPath := range repos {
-			repoName := naming.GetRepoName(repoPath)
-			wts, _ := git.ListWorktrees(repoPath)
+// buildItems turns worktrees and sessions into the items of both tabs.
+// Entries must already be paired with their sessions.
+func buildItems(entries []workspace.Entry, tmuxSessions []tmux.Session) (repoItems, sessionItems []Item) {
+	worktreeOrphans := make(map[string]*task.Orphan)
+	var sessionOrphans []task.Orphan
+	for _, o := range task.FindOrphans(entries, tmuxSessions) {
+		if o.Kind == task.OrphanWorktree {
+			worktreeOrphans[o.Path] = &o
+		} else {
+			sessionOrphans = append(sessionOrphans, o)
+		}
+	}
 
-			for _, wt := range wts {
-				slug := naming.GetSlugFromWorktree(wt.Path, repoName, wt.IsMain)
-				sessionName := naming.GetSessionName(repoName, slug)
+	for _, e := range entries {
+		statusStr := ""
+		if e.Status != nil {
+			statusStr = fmt.Sprintf("M:%d A:%d U:%d", e.Status.Modified, e.Status.Added, e.Status.Untracked)
+		}
 
-				status, _ := git.GetStatus(wt.Path)
-				isDirty := status != nil && status.IsDirty()
+		title := e.Slug
+		if e.IsRoot {
+			title = "(root) " + e.RepoName
+		}
 
-				statusStr := ""
-				if status != nil {
-					statusStr = fmt.Sprintf("M:%d A:%d U:%d", status.Modified, status.Added, status.Untracked)
-				}
+		var session tmux.Session
+		if e.Session != nil {
+			session = *e.Session
+		}
 
-				title := slug
-				if naming.IsRoot(slug, repoName, wt.Path, wt.IsMain) {
-					title = "(root) " + repoName
-				}
+		item := Item{
+			TitleStr:    title,
+			DescStr:     fmt.Sprintf("%s • %s", e.Branch, statusStr),
+			Path:        e.Path,
+			SessionName: e.SessionName,
+			IsAttached:  session.Attached,
+			IsDirty:     e.IsDirty(),
+			Windows:     session.Windows,
+			HasSession:  e.HasSession(),
+			RecentTime:  e.RecentTime,
+			Type:        ItemTypeRepo,
+			Entry:       e,
+			Orphan:      worktreeOrphans[e.Path],
+		}
+		repoItems = append(repoItems, item)
 
-				session, hasSession := sessionMap[sessionName]
-				recentTime := recent.GetCombinedRecentTime(wt.Path)
-				item := Item{
-					TitleStr:    title,
-					DescStr:     fmt.Sprintf("%s • %s", wt.Branch, statusStr),
-					Path:        wt.Path,
-					SessionName: sessionName,
-					IsAttached:  hasSession && session.Attached,
-					IsDirty:     isDirty,
-					Windows:     session.Windows,
-					HasSession:  hasSession,
-					RecentTime:  recentTime,
-					Type:        ItemTypeRepo,
-				}
-				repoItems = append(repoItems, item)
+		if item.HasSession {
+			item.Type = ItemTypeSession
+			sessionItems = append(sessionItems, item)
+		}
+	}
 
-				if hasSession {
-					item.Type = ItemTypeSession
-					sessionItems = append(sessionItems, item)
-				}
+	// Sessions whose worktree is gone only appear in the Sessions tab
+	for _, o := range sessionOrphans {
+		var session tmux.Session
+		for _, s := range tmuxSessions {
+			if s.Name == o.SessionName {
+				session = s
 			}
 		}
-
-		sort.Slice(repoItems, func(i, j int) bool {
-			return repoItems[i].TitleStr < repoItems[j].TitleStr
+		sessionItems = append(sessionItems, Item{
+			TitleStr:    o.SessionName,
+			DescStr:     o.Reason(),
+			Path:        o.Path,
+			SessionName: o.SessionName,
+			IsAttached:  session.Attached,
+			Windows:     session.Windows,
+			HasSession:  true,
+			Type:        ItemTypeSession,
+			Orphan:      &o,
 		})
-
-		return dataLoadedMsg{
-			repos:    repoItems,
-			sessions: sessionItems,
-		}
 	}
+
+	sort.Slice(repoItems, func(i, j int) bool {
+		return repoItems[i].TitleStr < repoItems[j].TitleStr
+	})
+	return repoItems, sessionItems
 }
 
 func fuzzyFilter(term string, targets []string) []list.Rank {
@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// TestLoadData runs the picker's loader against scripted git and tmux.
+func TestLoadData(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	src := filepath.Join(home, "src")
+	repo := filepath.Join(src, "api")
+	auth := filepath.Join(repo, ".worktrees", "auth")
+	for _, dir := range []string{filepath.Join(repo, ".git"), auth, filepath.Join(home, ".config", "tmux-worktree-tui")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config := `{"search_paths": ["` + src + `"], "depth": 2}`
+	if err := os.WriteFile(filepath.Join(home, ".config", "tmux-worktree-tui", "config.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	porcelain := "worktree " + repo + "\nbranch refs/heads/main\n\n" +
+		"worktree " + auth + "\nbranch refs/heads/task/auth\n\n"
+	fakeGit := runner.NewFake(
+		runner.Step{Argv: []string{"git", "worktree", "list", "--porcelain"}, Stdout: porcelain, Repeat: true},
+		runner.Step{Argv: []string{"git", "status", "--porcelain"}, Stdout: " M main.go\n", Repeat: true},
+	)
+	sessions := "api_auth|||$1|||2|||1|||0|||0|||0|||||||||" + auth + "|||" + auth + "\n" +
+		"api_old|||$2|||1|||0|||0|||0|||0|||||||||/gone/.worktrees/old|||\n"
+	fakeTmux := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "list-sessions", "*"}, Stdout: sessions, Repeat: true},
+	)
+
+	oldGit, oldTmux := git.Runner, tmux.Runner
+	git.Runner, tmux.Runner = fakeGit, fakeTmux
+	t.Cleanup(func() { git.Runner, tmux.Runner = oldGit, oldTmux })
+
+	msg, ok := loadDataCmd()().(dataLoadedMsg)
+	if !ok {
+		t.Fatal("loadDataCmd did not return dataLoadedMsg")
+	}
+	repoItems, sessionItems := buildItems(msg.entries, msg.sessions)
+
+	if len(repoItems) != 2 {
+		t.Fatalf("got %d project items, expected 2", len(repoItems))
+	}
+	if repoItems[0].TitleStr != "(root) api" || repoItems[1].TitleStr != "auth" {
+		t.Errorf("unexpected titles %q, %q", repoItems[0].TitleStr, repoItems[1].TitleStr)
+	}
+	if !repoItems[1].HasSession || !repoItems[1].IsAttached || !repoItems[1].IsDirty {
+		t.Errorf("auth should have an attached session and be dirty: %+v", repoItems[1])
+	}
+
+	if len(sessionItems) != 2 {
+		t.Fatalf("got %d session items, expected 2", len(sessionItems))
+	}
+	if orphan := sessionItems[1]; orphan.SessionName != "api_old" || orphan.Orphan == nil {
+		t.Errorf("api_old should be listed as an orphan session: %+v", orphan)
+	}
+}
+
+// TestApplySessions feeds a session change to a loaded picker, as the
+// control-mode watch does.
+func TestApplySessions(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "api")
+	entries := []workspace.Entry{
+		{RepoName: "api", Path: repo, Slug: "main", SessionName: "api_main", IsRoot: true},
+		{RepoName: "api", Path: filepath.Join(repo, ".worktrees", "auth"), Slug: "auth", SessionName: "api_auth"},
+		{RepoName: "api", Path: filepath.Join(repo, ".worktrees", "docs"), Slug: "docs", SessionName: "api_docs"},
+	}
+	for _, e := range entries {
+		if err := os.MkdirAll(e.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewModel(Options{Sort: SortByActive})
+	m.list.SetSize(80, 20)
+	m, _ = updateModel(m, dataLoadedMsg{entries: entries, sessions: []tmux.Session{
+		{Name: "api_auth", Windows: 1, Workdir: entries[1].Path},
+	}})
+
+	// Select docs, which moves to the top once its session starts
+	m.list.Select(2)
+	if i := m.list.SelectedItem().(Item); i.SessionName != "api_docs" {
+		t.Fatalf("selected %q, expected api_docs", i.SessionName)
+	}
+
+	m.watch = watch{control: &tmux.Control{}, session: "api_docs"}
+	m, _ = updateModel(m, sessionsRefreshedMsg{sessions: []tmux.Session{
+		{Name: "api_auth", Windows: 1, Workdir: entries[1].Path},
+		{Name: "api_docs", Windows: 3, Attached: true, Clients: 1, Workdir: entries[2].Path},
+	}})
+
+	i := m.list.SelectedItem().(Item)
+	if i.SessionName != "api_docs" {
+		t.Fatalf("selection moved to %q", i.SessionName)
+	}
+	if !i.HasSession || i.Windows != 3 {
+		t.Errorf("docs should have a session with 3 windows: %+v", i)
+	}
+	if i.IsAttached {
+		t.Error("the control client should not count as attached")
+	}
+	if len(m.allSessions) != 2 {
+		t.Errorf("got %d session items, expected 2", len(m.allSessions))
+	}
+}
+
+func TestApplySessionsFiltered(t *testing.T) {
+	repo := filepath.Join(t.TempDir(), "api")
+	entries := []workspace.Entry{
+		{RepoName: "api", Path: filepath.Join(repo, ".worktrees", "auth"), Slug: "auth", SessionName: "api_auth"},
+		{RepoName: "api", Path: filepath.Join(repo, ".worktrees", "docs"), Slug: "docs", SessionName: "api_docs"},
+		{RepoName: "api", Path: filepath.Join(repo, ".worktrees", "deploy"), Slug: "deploy", SessionName: "api_deploy"},
+	}
+	for _, e := range entries {
+		if err := os.MkdirAll(e.Path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	auth := tmux.Session{ID: "$1", Name: "api_auth", Windows: 1, Workdir: entries[0].Path}
+	m := NewModel(Options{Sort: SortByActive})
+	m.list.SetSize(80, 20)
+	m, _ = updateModel(m, dataLoadedMsg{entries: entries, sessions: []tmux.Session{auth}})
+
+	// Filtered to deploy and docs
+	m.list.SetFilterText("d")
+	for index, item := range m.list.VisibleItems() {
+		if item.(Item).SessionName == "api_deploy" {
+			m.list.Select(index)
+		}
+	}
+
+	deploy := tmux.Session{ID: "$2", Name: "api_deploy", Windows: 1, Workdir: entries[2].Path, HasWorkdir: true}
+	m, _ = updateModel(m, sessionsRefreshedMsg{sessions: []tmux.Session{auth, deploy}})
+	if i := m.list.SelectedItem().(Item); i.SessionName != "api_deploy" || !i.HasSession {
+		t.Fatalf("selection moved to %+v", i)
+	}
+
+	// A window count change updates the item where it is
+	deploy.Windows = 4
+	m, _ = updateModel(m, sessionsRefreshedMsg{sessions: []tmux.Session{auth, deploy}})
+	if i := m.list.SelectedItem().(Item); i.SessionName != "api_deploy" || i.Windows != 4 {
+		t.Errorf("selected %+v, expected deploy with 4 windows", i)
+	}
+	if n := len(m.list.VisibleItems()); n != 2 || m.list.FilterState() != list.FilterApplied {
+		t.Errorf("the filter should still show 2 items, got %d (%v)", n, m.list.FilterState())
+	}
+
+	// A renamed session keeps its worktree selected
+	deploy.Name = "api_ship"
+	m, _ = updateModel(m, sessionRefreshedMsg{session: deploy})
+	if i := m.list.SelectedItem().(Item); i.SessionName != "api_ship" || i.Path != entries[2].Path {
+		t.Errorf("selected %+v after the rename", i)
+	}
+}
+
+func TestExpandSession(t *testing.T) {
+	path := t.TempDir()
+	entries := []workspace.Entry{{RepoName: "api", Path: path, Slug: "auth", SessionName: "api_auth"}}
+	m := NewModel(Options{Tab: TabSessions})
+	m.list.SetSize(80, 20)
+	m, _ = updateModel(m, dataLoadedMsg{entries: entries, sessions: []tmux.Session{
+		{Name: "api_auth", Windows: 2, Workdir: path},
+	}})
+
+	m, cmd := updateModel(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if cmd == nil {
+		t.Fatal("expanding a session should list its panes")
+	}
+	m, _ = updateModel(m, panesLoadedMsg{session: "api_auth", panes: []tmux.Pane{
+		{ID: "%0", WindowIndex: 0, WindowName: "editor", Command: "nvim", Path: path},
+		{ID: "%3", WindowIndex: 1, WindowName: "server", Index: 1, Command: "node", Path: path},
+	}})
+
+	if n := len(m.list.Items()); n != 3 {
+		t.Fatalf("got %d items, expected the session and its 2 panes", n)
+	}
+	if i := m.list.SelectedItem().(Item); i.Pane != nil {
+		t.Errorf("the session should stay selected, got %q", i.TitleStr)
+	}
+
+	m.list.Select(2)
+	m, _ = updateModel(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.AttachSession == nil || m.AttachSession.Target() != "=api_auth:.%3" {
+		t.Fatalf("selecting the pane should target it: %+v", m.AttachSession)
+	}
+
+	// Collapsing from a pane selects its session again
+	m.AttachSession = nil
+	m, _ = updateModel(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if n := len(m.list.Items()); n != 1 {
+		t.Fatalf("got %d items after collapsing, expected 1", n)
+	}
+	if i := m.list.SelectedItem().(Item); i.SessionName != "api_auth" || i.Pane != nil {
+		t.Errorf("selected %+v after collapsing", i)
+	}
+}
+
+func updateModel(m Model, msg tea.Msg) (Model, tea.Cmd) {
+	updated, cmd := m.Update(msg)
+	return updated.(Model), cmd
+}
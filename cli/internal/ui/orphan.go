@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+type orphanFixedMsg struct {
+	result string
+	err    error
+}
+
+// orphanFix returns the in-place fix for an orphan item. A worktree without
+// a session gets its session started; a session without a worktree is
+// killed after confirmation.
+func orphanFix(i Item) (*confirmation, tea.Cmd) {
+	o := i.Orphan
+
+	if o.Kind == task.OrphanWorktree {
+		return nil, func() tea.Msg {
+			err := layout.CreateSessionFor(o.SessionName, o.Path)
+			return orphanFixedMsg{result: "Started session " + o.SessionName, err: err}
+		}
+	}
+
+	kill := func() tea.Msg {
+		err := tmux.KillSession(o.SessionName)
+		return orphanFixedMsg{result: "Killed session " + o.SessionName, err: err}
+	}
+	return &confirmation{
+		question: fmt.Sprintf("Session %s has no worktree (%s). Kill it?", o.SessionName, o.Reason()),
+		actions:  map[string]confirmAction{"y": {"Kill session", kill}},
+	}, nil
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+type panesLoadedMsg struct {
+	session string
+	panes   []tmux.Pane
+	err     error
+	refresh bool // listed again for an already expanded session
+}
+
+func listPanesCmd(session string, refresh bool) tea.Cmd {
+	return func() tea.Msg {
+		panes, err := tmux.ListPanes(session)
+		return panesLoadedMsg{session: session, panes: panes, err: err, refresh: refresh}
+	}
+}
+
+// refreshPanesCmd lists the panes of every expanded session again.
+func (m Model) refreshPanesCmd() tea.Cmd {
+	sessions := make([]string, 0, len(m.expanded))
+	for session := range m.expanded {
+		sessions = append(sessions, session)
+	}
+	sort.Strings(sessions)
+
+	var cmds []tea.Cmd
+	for _, session := range sessions {
+		cmds = append(cmds, listPanesCmd(session, true))
+	}
+	return tea.Batch(cmds...)
+}
+
+// toggleExpanded shows or hides the windows and panes of the selected
+// session in the Sessions tab.
+func (m *Model) toggleExpanded() tea.Cmd {
+	i, ok := m.list.SelectedItem().(Item)
+	switch {
+	case !ok:
+		return nil
+	case m.activeTab != TabSessions:
+		m.notice = "Windows are listed in the Sessions tab"
+		return nil
+	case !i.HasSession:
+		m.notice = "No session"
+		return nil
+	}
+
+	if _, ok := m.expanded[i.SessionName]; ok {
+		delete(m.expanded, i.SessionName)
+		return m.refreshListSelecting(func(item Item) bool {
+			return item.Pane == nil && item.SessionName == i.SessionName
+		})
+	}
+	return listPanesCmd(i.SessionName, false)
+}
+
+func (m *Model) updatePanes(msg panesLoadedMsg) tea.Cmd {
+	if _, ok := m.expanded[msg.session]; msg.refresh && !ok {
+		return nil // collapsed in the meantime
+	}
+
+	if msg.err != nil {
+		if !msg.refresh {
+			m.notice = fmt.Sprintf("Cannot list windows: %v", msg.err)
+		}
+		// A session that has ended loses its item with the next refresh
+		delete(m.expanded, msg.session)
+	} else {
+		m.expanded[msg.session] = msg.panes
+	}
+	return m.refreshListKeepSelection()
+}
+
+// paneItems lists the panes of a session as items to show below it.
+func paneItems(session Item, panes []tmux.Pane) []Item {
+	if !session.HasSession {
+		return nil
+	}
+
+	var items []Item
+	for _, p := range panes {
+		p := p
+		items = append(items, Item{
+			TitleStr:    fmt.Sprintf("%d.%d %s", p.WindowIndex, p.Index, p.WindowName),
+			DescStr:     p.Command,
+			Path:        p.Path,
+			SessionName: session.SessionName,
+			IsAttached:  session.IsAttached,
+			HasSession:  true,
+			Type:        ItemTypePane,
+			Entry:       session.Entry,
+			Pane:        &p,
+		})
+	}
+	return items
+}
+
+// sameItem reports whether a and b show the same worktree, session or
+// pane, so the selection can follow it across refreshes.
+func sameItem(a, b Item) bool {
+	if a.Pane != nil || b.Pane != nil {
+		return a.Pane != nil && b.Pane != nil && a.SessionName == b.SessionName && a.Pane.ID == b.Pane.ID
+	}
+	return a.Path == b.Path && a.SessionName == b.SessionName
+}
@@ -0,0 +1,152 @@
+package ui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+const (
+	// previewInterval is how often the selected session is captured again.
+	previewInterval = 500 * time.Millisecond
+
+	// previewMinWidth is the narrowest terminal that fits the list and the
+	// preview side by side.
+	previewMinWidth = 80
+)
+
+// preview holds the captured screen of the selected session.
+type preview struct {
+	on      bool
+	target  string // tmux target the content belongs to
+	content string
+	err     error
+	gen     int // bumped on every toggle so ticks of an older loop stop
+}
+
+type previewTickMsg struct{ gen int }
+
+type previewMsg struct {
+	target  string
+	content string
+	err     error
+}
+
+func capturePreviewCmd(target string) tea.Cmd {
+	if target == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		content, err := tmux.CaptureScreen(target)
+		return previewMsg{target: target, content: content, err: err}
+	}
+}
+
+func previewTickCmd(gen int) tea.Cmd {
+	return tea.Tick(previewInterval, func(time.Time) tea.Msg {
+		return previewTickMsg{gen: gen}
+	})
+}
+
+// togglePreview shows or hides the preview and starts its refresh loop.
+func (m *Model) togglePreview() tea.Cmd {
+	m.preview.on = !m.preview.on
+	m.preview.gen++
+	m.resize()
+
+	if !m.preview.on {
+		return nil
+	}
+	m.preview.target = m.selectedTarget()
+	m.preview.content, m.preview.err = "", nil
+	return tea.Batch(capturePreviewCmd(m.preview.target), previewTickCmd(m.preview.gen))
+}
+
+// syncPreview captures the newly selected session right away instead of
+// waiting for the next tick.
+func (m *Model) syncPreview() tea.Cmd {
+	if !m.preview.on {
+		return nil
+	}
+	target := m.selectedTarget()
+	if target == m.preview.target {
+		return nil
+	}
+	m.preview.target = target
+	m.preview.content, m.preview.err = "", nil
+	return capturePreviewCmd(target)
+}
+
+// selectedTarget returns the pane to preview: the selected pane, or the
+// active pane of the selected session's current window.
+func (m Model) selectedTarget() string {
+	i, ok := m.list.SelectedItem().(Item)
+	switch {
+	case !ok || !i.HasSession:
+		return ""
+	case i.Pane != nil:
+		return tmux.PaneTarget(i.SessionName, i.Pane.ID)
+	}
+	return "=" + i.SessionName + ":"
+}
+
+// showPreview reports whether the preview is on and the terminal is wide
+// enough to show it.
+func (m Model) showPreview() bool {
+	return m.preview.on && m.width >= previewMinWidth
+}
+
+// previewWidth is the width of the preview panel, borders included.
+func (m Model) previewWidth() int {
+	if !m.showPreview() {
+		return 0
+	}
+	return m.width * 3 / 5
+}
+
+func (m Model) viewPreview(width, height int) string {
+	innerWidth, innerHeight := width-2, height-3 // border and title
+	if innerWidth < 1 || innerHeight < 1 {
+		return ""
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(m.preview.target, "="), ":")
+	title := previewTitleStyle.Render(ansi.Truncate(name, innerWidth, "…"))
+	var body string
+	switch {
+	case m.preview.target == "":
+		title = previewTitleStyle.Render("Preview")
+		body = previewEmptyStyle.Render("No session")
+	case m.preview.err != nil:
+		body = previewEmptyStyle.Render("Cannot capture pane")
+	default:
+		body = cropScreen(m.preview.content, innerWidth, innerHeight)
+	}
+
+	return previewStyle.
+		Width(innerWidth).
+		Height(innerHeight + 1).
+		Render(title + "\n" + body)
+}
+
+// cropScreen fits a captured screen into width x height. Trailing blank
+// lines are dropped and the bottom of the screen is kept, since that is
+// where shells and agents print their latest output.
+func cropScreen(screen string, width, height int) string {
+	lines := strings.Split(strings.TrimRight(screen, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(ansi.Strip(lines[len(lines)-1])) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > height {
+		lines = lines[len(lines)-height:]
+	}
+
+	for i, line := range lines {
+		// Reset so colors left open by the pane don't bleed into the border
+		lines[i] = ansi.Truncate(line, width, "") + ansi.ResetStyle
+	}
+	return strings.Join(lines, "\n")
+}
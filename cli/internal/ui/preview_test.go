@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestCropScreen(t *testing.T) {
+	screen := "one\n\x1b[31mtwo is red and long\n\x1b[0mthree\n\n\n"
+
+	got := strings.Split(cropScreen(screen, 6, 2), "\n")
+	if len(got) != 2 {
+		t.Fatalf("got %d lines, expected the last 2 non-blank ones", len(got))
+	}
+	if plain := ansi.Strip(got[0]); plain != "two is" {
+		t.Errorf("first line = %q, expected it cut to 6 columns", plain)
+	}
+	if !strings.HasPrefix(got[0], "\x1b[31m") {
+		t.Errorf("first line lost its color: %q", got[0])
+	}
+	for _, line := range got {
+		if !strings.HasSuffix(line, ansi.ResetStyle) {
+			t.Errorf("line %q does not reset its style", line)
+		}
+	}
+}
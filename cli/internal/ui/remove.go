@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+)
+
+// removalCheckedMsg carries the safety check for a worktree the user wants
+// to remove. removeConfirmation turns it into the question in the status
+// bar.
+type removalCheckedMsg struct {
+	item  Item
+	check task.RemovalCheck
+}
+
+type removedMsg struct {
+	item Item
+	err  error
+}
+
+func checkRemovalCmd(i Item) tea.Cmd {
+	return func() tea.Msg {
+		return removalCheckedMsg{item: i, check: task.Check(i.Entry)}
+	}
+}
+
+func removeCmd(i Item, opts task.RemoveOptions) tea.Cmd {
+	return func() tea.Msg {
+		return removedMsg{item: i, err: task.Remove(i.Entry, opts)}
+	}
+}
+
+// removeConfirmation asks before removing a worktree, listing anything that
+// would be lost.
+func removeConfirmation(msg removalCheckedMsg) *confirmation {
+	question := fmt.Sprintf("Remove %s?", msg.item.TitleStr)
+	if !msg.check.Safe() {
+		question = fmt.Sprintf("⚠ %s: %s. Remove anyway?",
+			msg.item.TitleStr, strings.Join(msg.check.Problems(), ", "))
+	}
+
+	// The user has seen the warnings, so confirming forces the removal
+	opts := task.RemoveOptions{Force: !msg.check.Safe()}
+	withBranch := opts
+	withBranch.DeleteBranch = true
+
+	return &confirmation{
+		question: question,
+		actions: map[string]confirmAction{
+			"y": {"Remove", removeCmd(msg.item, opts)},
+			"b": {"Remove + delete branch", removeCmd(msg.item, withBranch)},
+		},
+	}
+}
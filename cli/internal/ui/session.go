@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/task"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+type sessionChangedMsg struct {
+	result string
+	err    error
+}
+
+// killConfirmation asks before killing the item's session and the
+// sessions grouped with it.
+func killConfirmation(i Item) *confirmation {
+	kill := func() tea.Msg {
+		err := tmux.KillSessionGroup(i.SessionName)
+		return sessionChangedMsg{result: "Killed session " + i.SessionName, err: err}
+	}
+
+	question := fmt.Sprintf("Kill session %s?", i.SessionName)
+	if i.IsAttached {
+		question = fmt.Sprintf("⚠ Session %s is attached. Kill it anyway?", i.SessionName)
+	}
+	return &confirmation{
+		question: question,
+		actions:  map[string]confirmAction{"y": {"Kill session", kill}},
+	}
+}
+
+// renameInput is the status bar field where the new name is typed. Task
+// worktrees are renamed by slug, other sessions by their full name.
+type renameInput struct {
+	item  Item
+	input textinput.Model
+}
+
+func newRenameInput(i Item) *renameInput {
+	ti := textinput.New()
+	ti.Prompt = ""
+	ti.SetValue(i.SessionName)
+	if isTaskItem(i) {
+		ti.CharLimit = naming.MaxSlugLength
+		ti.SetValue(i.Entry.Slug)
+	}
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	ti.CursorEnd()
+	ti.Focus()
+	return &renameInput{item: i, input: ti}
+}
+
+func (r renameInput) prompt() string {
+	if isTaskItem(r.item) {
+		return fmt.Sprintf("New slug for %s: %s", r.item.SessionName, r.input.View())
+	}
+	return fmt.Sprintf("Rename session %s to: %s", r.item.SessionName, r.input.View())
+}
+
+// isTaskItem reports whether the item is a linked worktree, whose slug
+// determines its session name.
+func isTaskItem(i Item) bool {
+	return i.Entry.Path != "" && !i.Entry.IsRoot
+}
+
+// updateRename handles keys while the new name is being typed.
+func (m Model) updateRename(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.rename = nil
+		return m, nil
+	case "enter":
+		item, value := m.rename.item, strings.TrimSpace(m.rename.input.Value())
+		m.rename = nil
+		m.confirm, m.notice = renameConfirmation(item, value)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.rename.input, cmd = m.rename.input.Update(msg)
+	return m, cmd
+}
+
+// renameConfirmation asks how to apply the rename, or returns a notice when
+// there is nothing to confirm.
+func renameConfirmation(i Item, value string) (*confirmation, string) {
+	if !isTaskItem(i) {
+		if value == "" || value == i.SessionName {
+			return nil, ""
+		}
+		rename := func() tea.Msg {
+			err := tmux.RenameSession(i.SessionName, value)
+			return sessionChangedMsg{result: "Renamed session to " + value, err: err}
+		}
+		return &confirmation{
+			question: fmt.Sprintf("Rename session %s to %s?", i.SessionName, value),
+			actions:  map[string]confirmAction{"y": {"Rename", rename}},
+		}, ""
+	}
+
+	slug := naming.NormalizeSlug(value)
+	if err := naming.ValidateSlug(slug); err != nil {
+		return nil, fmt.Sprintf("Rename failed: %v", err)
+	}
+	if slug == i.Entry.Slug {
+		return nil, ""
+	}
+
+	actions := map[string]confirmAction{
+		"m": {"Move worktree + branch too", renameCmd(i, slug, task.RenameOptions{MoveWorktree: true})},
+	}
+	if i.HasSession {
+		actions["s"] = confirmAction{"Session only", renameCmd(i, slug, task.RenameOptions{})}
+	}
+	return &confirmation{
+		question: fmt.Sprintf("Rename %s to %s?", i.SessionName, naming.GetSessionName(i.Entry.RepoName, slug)),
+		actions:  actions,
+	}, ""
+}
+
+func renameCmd(i Item, slug string, opts task.RenameOptions) tea.Cmd {
+	return func() tea.Msg {
+		sessionName, err := task.Rename(i.Entry, slug, opts)
+		return sessionChangedMsg{result: "Renamed to " + sessionName, err: err}
+	}
+}
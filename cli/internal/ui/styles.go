@@ -8,6 +8,7 @@ var (
 	cPrimary    = lipgloss.Color("#58A6FF") // Bright Blue
 	cSubtle     = lipgloss.Color("#6E7681") // Subtle Text/Comments
 	cWarning    = lipgloss.Color("#D29922") // Orange
+	cError      = lipgloss.Color("#F85149") // Red
 	cText       = lipgloss.Color("#C9D1D9") // Main Text
 	cDim        = lipgloss.Color("#484F58") // Very Dim / Borders
 	cBgSelected = lipgloss.Color("#161B22") // List Selection BG
@@ -62,6 +63,24 @@ var (
 				Bold(true).
 				PaddingLeft(1)
 
+	orphanStyle = lipgloss.NewStyle().
+			Foreground(cError).
+			Bold(true).
+			PaddingLeft(1)
+
+	// Preview
+	previewStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(cDim)
+
+	previewTitleStyle = lipgloss.NewStyle().
+				Foreground(cPrimary).
+				Bold(true)
+
+	previewEmptyStyle = lipgloss.NewStyle().
+				Foreground(cSubtle).
+				Italic(true)
+
 	// Status Bar
 	statusBarStyle = lipgloss.NewStyle().
 			Foreground(cSubtle).
@@ -0,0 +1,324 @@
+package ui
+
+import (
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// controlRetryInterval is how long to wait before attaching a new control
+// client, e.g. while the server has no sessions.
+const controlRetryInterval = 5 * time.Second
+
+// watch follows session changes through a tmux control-mode client, so the
+// list stays current without reloading every worktree.
+type watch struct {
+	control *tmux.Control
+	session string // session the control client is attached to
+}
+
+type controlStartedMsg struct {
+	control *tmux.Control
+	err     error
+}
+
+type controlEventMsg struct {
+	control *tmux.Control
+	events  []tmux.Event
+}
+
+type controlClosedMsg struct{ control *tmux.Control }
+
+type controlRetryMsg struct{}
+
+type sessionsRefreshedMsg struct{ sessions []tmux.Session }
+
+type sessionRefreshedMsg struct {
+	session tmux.Session
+	err     error
+}
+
+func startControlCmd() tea.Cmd {
+	return func() tea.Msg {
+		c, err := tmux.StartControl()
+		return controlStartedMsg{control: c, err: err}
+	}
+}
+
+func controlRetryCmd() tea.Cmd {
+	return tea.Tick(controlRetryInterval, func(time.Time) tea.Msg {
+		return controlRetryMsg{}
+	})
+}
+
+// waitEventCmd waits for the next notification and takes any that arrived
+// with it, so a burst such as a rename causes a single refresh.
+func waitEventCmd(c *tmux.Control) tea.Cmd {
+	return func() tea.Msg {
+		e, ok := <-c.Events()
+		if !ok {
+			return controlClosedMsg{control: c}
+		}
+
+		events := []tmux.Event{e}
+		for {
+			select {
+			case e, ok := <-c.Events():
+				if !ok {
+					return controlEventMsg{control: c, events: events}
+				}
+				events = append(events, e)
+			default:
+				return controlEventMsg{control: c, events: events}
+			}
+		}
+	}
+}
+
+func refreshSessionsCmd() tea.Cmd {
+	return func() tea.Msg {
+		sessions, _ := tmux.ListSessions()
+		return sessionsRefreshedMsg{sessions: sessions}
+	}
+}
+
+// refreshSessionCmd fetches the session with the given ID only, for a
+// notification about that session alone.
+func refreshSessionCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		s, err := tmux.GetSession(id)
+		return sessionRefreshedMsg{session: s, err: err}
+	}
+}
+
+// refresh lists the sessions again if the watch is running, to catch
+// changes made before the control client attached.
+func (w watch) refresh() tea.Cmd {
+	if w.control == nil {
+		return nil
+	}
+	return refreshSessionsCmd()
+}
+
+// Close detaches the control client.
+func (w watch) Close() {
+	if w.control != nil {
+		w.control.Close()
+	}
+}
+
+func (m *Model) updateWatch(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case controlStartedMsg:
+		if msg.err != nil {
+			return controlRetryCmd()
+		}
+		m.watch = watch{control: msg.control}
+		return tea.Batch(waitEventCmd(msg.control), refreshSessionsCmd())
+
+	case controlEventMsg:
+		if msg.control != m.watch.control {
+			return nil
+		}
+		for _, e := range msg.events {
+			if e.Name == tmux.EventSessionChanged {
+				m.watch.session = e.SessionName()
+			}
+		}
+		return tea.Batch(waitEventCmd(msg.control), refreshEventsCmd(msg.events), m.refreshPanesCmd())
+
+	case controlClosedMsg:
+		// The client exits when its session is killed and no other is left,
+		// or when the server stops
+		if msg.control != m.watch.control {
+			return nil
+		}
+		m.watch.Close()
+		m.watch = watch{}
+		return tea.Batch(controlRetryCmd(), refreshSessionsCmd())
+
+	case controlRetryMsg:
+		return startControlCmd()
+
+	case sessionRefreshedMsg:
+		if msg.err != nil {
+			return refreshSessionsCmd()
+		}
+		sessions := slices.Clone(m.sessions)
+		i := slices.IndexFunc(sessions, func(s tmux.Session) bool { return s.ID == msg.session.ID })
+		if i < 0 {
+			return refreshSessionsCmd()
+		}
+		sessions[i] = msg.session
+		return m.applySessions(sessions)
+	}
+	return nil
+}
+
+// refreshEventsCmd fetches what a burst of notifications changed: the
+// renamed sessions alone when that is all that happened, or else every
+// session.
+func refreshEventsCmd(events []tmux.Event) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, e := range events {
+		if e.Name != tmux.EventSessionRenamed || e.SessionID() == "" {
+			return refreshSessionsCmd()
+		}
+		cmds = append(cmds, refreshSessionCmd(e.SessionID()))
+	}
+	return tea.Batch(cmds...)
+}
+
+// applySessions replaces m.sessions. When only window and client counts
+// changed, the items showing them are updated in place; otherwise the
+// items are rebuilt, still without touching git or the filesystem.
+func (m *Model) applySessions(sessions []tmux.Session) tea.Cmd {
+	before := m.watchedSessions()
+	m.sessions = sessions
+	if changed, ok := countChanges(before, m.watchedSessions()); ok {
+		return m.patchSessions(changed)
+	}
+	return m.rebuildItems()
+}
+
+// countChanges returns the sessions of after whose window or client
+// counts differ from before. It fails when a session was created, killed,
+// renamed or moved to another directory, since that changes which items
+// there are.
+func countChanges(before, after []tmux.Session) (map[string]tmux.Session, bool) {
+	if len(before) != len(after) {
+		return nil, false
+	}
+	old := make(map[string]tmux.Session, len(before))
+	for _, s := range before {
+		old[s.Name] = s
+	}
+
+	changed := map[string]tmux.Session{}
+	for _, s := range after {
+		o, ok := old[s.Name]
+		if !ok || o.ID != s.ID || o.Workdir != s.Workdir || o.HasWorkdir != s.HasWorkdir {
+			return nil, false
+		}
+		if o.Windows != s.Windows || o.Attached != s.Attached {
+			changed[s.Name] = s
+		}
+	}
+	return changed, true
+}
+
+// patchSessions updates the items of the changed sessions, keyed by name,
+// leaving the others and the selection alone.
+func (m *Model) patchSessions(changed map[string]tmux.Session) tea.Cmd {
+	if len(changed) == 0 {
+		return nil
+	}
+	patch := func(item *Item) bool {
+		s, ok := changed[item.SessionName]
+		if !ok || !item.HasSession {
+			return false
+		}
+		item.Windows, item.IsAttached = s.Windows, s.Attached
+		if item.Entry.Session != nil {
+			item.Entry.Session = &s
+		}
+		return true
+	}
+
+	for i := range m.entries {
+		if s, ok := changed[m.entries[i].SessionName]; ok && m.entries[i].Session != nil {
+			m.entries[i].Session = &s
+		}
+	}
+	for i := range m.allRepos {
+		patch(&m.allRepos[i])
+	}
+	for i := range m.allSessions {
+		patch(&m.allSessions[i])
+	}
+
+	// Each SetItem filters all items again, so the last command is enough
+	var cmd tea.Cmd
+	for index, li := range m.list.Items() {
+		if item, ok := li.(Item); ok && patch(&item) {
+			cmd = m.list.SetItem(index, item)
+		}
+	}
+	return m.settleFilter(cmd)
+}
+
+// rebuildItems pairs the loaded worktrees with m.sessions and rebuilds the
+// items.
+func (m *Model) rebuildItems() tea.Cmd {
+	sessions := m.watchedSessions()
+	m.entries = workspace.AttachSessions(m.entries, sessions)
+	m.allRepos, m.allSessions = buildItems(m.entries, sessions)
+
+	for session := range m.expanded {
+		if !slices.ContainsFunc(sessions, func(s tmux.Session) bool { return s.Name == session }) {
+			delete(m.expanded, session)
+		}
+	}
+	return m.refreshListKeepSelection()
+}
+
+// refreshListKeepSelection refreshes the list and selects the item that
+// was selected before, wherever it moved, or the worktree it showed if its
+// session was renamed. An applied filter is kept.
+func (m *Model) refreshListKeepSelection() tea.Cmd {
+	selected, ok := m.list.SelectedItem().(Item)
+	if !ok {
+		return m.refreshList()
+	}
+	return m.refreshListSelecting(
+		func(i Item) bool { return sameItem(i, selected) },
+		func(i Item) bool {
+			return selected.Pane == nil && i.Pane == nil && selected.Path != "" && i.Path == selected.Path
+		},
+	)
+}
+
+// refreshListSelecting refreshes the list and selects the first item the
+// first of matches accepts, trying the next if none does. Only the matches
+// of an applied filter are considered.
+func (m *Model) refreshListSelecting(matches ...func(Item) bool) tea.Cmd {
+	cmd := m.settleFilter(m.refreshList())
+	for _, match := range matches {
+		for index, item := range m.list.VisibleItems() {
+			if i, ok := item.(Item); ok && match(i) {
+				m.list.Select(index)
+				return cmd
+			}
+		}
+	}
+	return cmd
+}
+
+// settleFilter runs the filtering that cmd, as returned by SetItems or
+// SetItem, would do as a command, so that the visible items are
+// current and the cursor can be placed among them. Without a filter it
+// returns cmd unchanged.
+func (m *Model) settleFilter(cmd tea.Cmd) tea.Cmd {
+	if cmd == nil || m.list.FilterState() == list.Unfiltered {
+		return cmd
+	}
+	m.list, _ = m.list.Update(cmd())
+	return nil
+}
+
+// watchedSessions returns m.sessions without the watch's own control
+// client among the attached clients.
+func (m Model) watchedSessions() []tmux.Session {
+	sessions := slices.Clone(m.sessions)
+	for i := range sessions {
+		if m.watch.control != nil && sessions[i].Name == m.watch.session && sessions[i].Clients > 0 {
+			sessions[i].Clients--
+			sessions[i].Attached = sessions[i].Clients > 0
+		}
+	}
+	return sessions
+}
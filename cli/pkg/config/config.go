@@ -3,13 +3,111 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
 )
 
 type Config struct {
 	SearchPaths []string `json:"search_paths"`
 	Depth       int      `json:"depth"`
+
+	// Editor is the command template used to open a worktree, such as
+	// "code {path}". Editors overrides it per project, keyed by repository
+	// name or path.
+	Editor  string            `json:"editor,omitempty"`
+	Editors map[string]string `json:"editors,omitempty"`
+
+	// Socket selects the tmux server: a socket name as for `tmux -L`, or a
+	// path as for `tmux -S`. Profile picks one of Profiles instead.
+	Socket   string             `json:"socket,omitempty"`
+	Profile  string             `json:"profile,omitempty"`
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// Attach is the default attach mode: "shared" (as plain `tmux
+	// attach`), "detach", "read-only" or "grouped".
+	Attach string `json:"attach,omitempty"`
+
+	// Layouts are applied to sessions twt creates. Layout names the default
+	// one; RepoLayouts overrides it per project, keyed by repository name
+	// or path.
+	Layouts     map[string]Layout `json:"layouts,omitempty"`
+	Layout      string            `json:"layout,omitempty"`
+	RepoLayouts map[string]string `json:"repo_layouts,omitempty"`
+
+	// Env holds environment variables for sessions twt creates. Values may
+	// use {repo}, {slug}, {branch} and {path}. RepoEnv adds to and
+	// overrides it per project, keyed by repository name or path.
+	Env     map[string]string            `json:"env,omitempty"`
+	RepoEnv map[string]map[string]string `json:"repo_env,omitempty"`
+
+	// Ports reserves a block of ports per worktree for those sessions.
+	Ports *Ports `json:"ports,omitempty"`
+
+	// RestoreCommands lists the commands `twt restore` runs again: program
+	// names such as "nvim" or command prefixes such as "npm run dev".
+	// Other saved commands are typed without pressing Enter.
+	RestoreCommands []string `json:"restore_commands,omitempty"`
+}
+
+// Ports configures the port blocks of worktrees. Blocks of Count ports are
+// handed out from From upwards and exported as PORT, PORT_1, ...
+type Ports struct {
+	From  int `json:"from,omitempty"` // default 3000
+	Count int `json:"count"`
+}
+
+// Layout describes the windows of a new session.
+type Layout struct {
+	Windows []Window `json:"windows"`
+}
+
+// Window is a named window. A window without Panes has a single pane
+// that starts in Dir and runs Command.
+type Window struct {
+	Name    string `json:"name,omitempty"`
+	Dir     string `json:"dir,omitempty"`
+	Command string `json:"command,omitempty"`
+	Layout  string `json:"layout,omitempty"` // tmux layout such as "main-vertical", applied after splitting
+	Panes   []Pane `json:"panes,omitempty"`
+}
+
+// Pane is one pane of a window. Every pane after the first splits the
+// one before it.
+type Pane struct {
+	Dir     string `json:"dir,omitempty"`     // relative to the worktree
+	Command string `json:"command,omitempty"` // typed into the pane's shell
+	Split   string `json:"split,omitempty"`   // "vertical" (stacked, default) or "horizontal" (side by side)
+	Size    string `json:"size,omitempty"`    // lines, columns or a percentage such as "30%"
+}
+
+// Profile is a named set of settings, selected by "profile" in the config
+// or the --profile flag.
+type Profile struct {
+	Socket string `json:"socket,omitempty"`
+}
+
+// ProfileSocket returns the tmux socket of the named profile, or of the
+// configured profile when name is empty, falling back to Socket.
+func (c *Config) ProfileSocket(name string) (string, error) {
+	if name == "" {
+		name = c.Profile
+	}
+	if name == "" {
+		return c.Socket, nil
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown profile %q", name)
+	}
+	if p.Socket != "" {
+		return p.Socket, nil
+	}
+	return c.Socket, nil
 }
 
 func GetConfigPath() (string, error) {
@@ -20,6 +118,65 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(home, ".config", "tmux-worktree-tui", "config.json"), nil
 }
 
+// ForRepo returns the entry of m for a repository, where m is keyed by
+// repository name or path like Editors. A name match wins over a path
+// match, and paths are tried in key order, so the result never depends on
+// map order.
+func ForRepo[V any](m map[string]V, repoName, repoPath string) (V, bool) {
+	if v, ok := m[repoName]; ok {
+		return v, true
+	}
+	if repoPath != "" {
+		for _, key := range slices.Sorted(maps.Keys(m)) {
+			if filepath.Clean(discovery.ExpandPath(key)) == filepath.Clean(repoPath) {
+				return m[key], true
+			}
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// GetStateDir returns the directory for state twt keeps between runs,
+// such as reserved ports: $XDG_STATE_HOME/tmux-worktree-tui, by default
+// under ~/.local/state.
+func GetStateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "tmux-worktree-tui"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "tmux-worktree-tui"), nil
+}
+
+// WriteStateFile replaces the file at path with data in one step, so a
+// concurrent reader never sees half of it. State may hold command lines
+// with secrets, so only the user can read it.
+func WriteStateFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with mode 0600
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
 func LoadConfig() (*Config, error) {
 	path, err := GetConfigPath()
 	if err != nil {
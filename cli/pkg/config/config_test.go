@@ -0,0 +1,23 @@
+package config
+
+import "testing"
+
+func TestForRepo(t *testing.T) {
+	m := map[string]string{
+		"/src/api": "by path",
+		"api":      "by name",
+		"/src/web": "web",
+	}
+	// Run it a few times, since map order changes from one range to the next
+	for range 20 {
+		if got, _ := ForRepo(m, "api", "/src/api"); got != "by name" {
+			t.Fatalf("ForRepo(api) = %q, want the name match", got)
+		}
+	}
+	if got, ok := ForRepo(m, "www", "/src/web/"); !ok || got != "web" {
+		t.Errorf("ForRepo(www) = %q, %v; want the path match", got, ok)
+	}
+	if _, ok := ForRepo(m, "blog", "/src/blog"); ok {
+		t.Error("ForRepo(blog) matched")
+	}
+}
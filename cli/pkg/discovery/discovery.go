@@ -31,6 +31,11 @@ func FindGitRepos(roots []string, maxDepth int) []string {
 	return repos
 }
 
+// ExpandPath expands a leading ~ the same way FindGitRepos does.
+func ExpandPath(path string) string {
+	return expandTilde(path)
+}
+
 // expandTilde replaces ~ with the user's home directory
 func expandTilde(path string) string {
 	if !strings.HasPrefix(path, "~") {
@@ -0,0 +1,256 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// Status is the outcome of a single check.
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Check is one line of the doctor report.
+type Check struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+}
+
+// minPopupTmuxVersion is the first tmux with display-popup, used by `twt popup`.
+var minPopupTmuxVersion = [2]int{3, 2}
+
+// minGitVersion is the oldest git that supports everything we run
+// (`rev-parse --path-format` was added in 2.31).
+var minGitVersion = [2]int{2, 31}
+
+// Run performs every check and returns them in report order.
+// Checks that depend on a missing tool are skipped rather than failed twice.
+func Run() []Check {
+	var checks []Check
+
+	tmuxCheck := checkTmux()
+	gitCheck := checkGit()
+	checks = append(checks, tmuxCheck, gitCheck)
+
+	cfg, cfgChecks := checkConfig()
+	checks = append(checks, cfgChecks...)
+
+	var repos []string
+	for _, path := range cfg.SearchPaths {
+		check, found := checkSearchPath(path, cfg.Depth)
+		checks = append(checks, check)
+		repos = append(repos, found...)
+	}
+
+	if gitCheck.Status == Fail {
+		return checks
+	}
+
+	var sessions []tmux.Session
+	if tmuxCheck.Status != Fail {
+		sessions, _ = tmux.ListSessions()
+	}
+	entries := workspace.Match(repos, sessions)
+
+	if tmuxCheck.Status != Fail {
+		checks = append(checks, checkWorkdirOptions(sessions))
+		checks = append(checks, checkSessionNames(entries, sessions))
+	}
+	checks = append(checks, checkDuplicateNames(entries))
+	checks = append(checks, checkPrunable(repos))
+
+	return checks
+}
+
+// Failed returns true if any check failed.
+func Failed(checks []Check) bool {
+	for _, c := range checks {
+		if c.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func checkTmux() Check {
+	version, err := tmux.Version()
+	if err != nil {
+		return Check{"tmux", Fail, "tmux not found in PATH"}
+	}
+
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "tmux %d.%d", &major, &minor); err == nil {
+		if older(major, minor, minPopupTmuxVersion) {
+			return Check{"tmux", Warn, fmt.Sprintf("%s is older than %d.%d; twt popup will not work",
+				version, minPopupTmuxVersion[0], minPopupTmuxVersion[1])}
+		}
+	}
+	if tmux.Server != (tmux.Socket{}) {
+		version += ", server " + tmux.Server.String()
+	}
+	return Check{"tmux", Pass, version}
+}
+
+func checkGit() Check {
+	version, err := git.Version()
+	if err != nil {
+		return Check{"git", Fail, "git not found in PATH"}
+	}
+
+	var major, minor int
+	if _, err := fmt.Sscanf(version, "git version %d.%d", &major, &minor); err == nil {
+		if older(major, minor, minGitVersion) {
+			return Check{"git", Warn, fmt.Sprintf("%s is older than %d.%d; some commands may fail",
+				version, minGitVersion[0], minGitVersion[1])}
+		}
+	}
+	return Check{"git", Pass, version}
+}
+
+// checkConfig returns the config to use for the remaining checks, which
+// falls back to the defaults when the file is missing or broken.
+func checkConfig() (*config.Config, []Check) {
+	defaults := &config.Config{SearchPaths: []string{}, Depth: 2}
+
+	path, err := config.GetConfigPath()
+	if err != nil {
+		return defaults, []Check{{"config", Fail, err.Error()}}
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return defaults, []Check{{"config", Warn, path + " does not exist; no search paths configured"}}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return defaults, []Check{{"config", Fail, fmt.Sprintf("%s: %v", path, err)}}
+	}
+
+	if len(cfg.SearchPaths) == 0 {
+		return cfg, []Check{{"config", Warn, path + " has no search_paths; nothing will be listed"}}
+	}
+	return cfg, []Check{{"config", Pass, path}}
+}
+
+func checkSearchPath(path string, depth int) (Check, []string) {
+	name := "search path " + path
+	expanded := discovery.ExpandPath(path)
+
+	info, err := os.Stat(expanded)
+	if err != nil {
+		return Check{name, Fail, fmt.Sprintf("%s does not exist", expanded)}, nil
+	}
+	if !info.IsDir() {
+		return Check{name, Fail, fmt.Sprintf("%s is not a directory", expanded)}, nil
+	}
+
+	repos := discovery.FindGitRepos([]string{path}, depth)
+	if len(repos) == 0 {
+		return Check{name, Warn, fmt.Sprintf("no git repositories within depth %d", depth)}, nil
+	}
+	return Check{name, Pass, fmt.Sprintf("%d repositories", len(repos))}, repos
+}
+
+// checkWorkdirOptions finds sessions without @workdir, as read by the
+// single list-sessions call.
+func checkWorkdirOptions(sessions []tmux.Session) Check {
+	var missing []string
+	for _, s := range sessions {
+		if !s.HasWorkdir {
+			missing = append(missing, s.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return Check{"session @workdir", Warn, "no @workdir set: " + strings.Join(missing, ", ")}
+	}
+	return Check{"session @workdir", Pass, fmt.Sprintf("%d sessions", len(sessions))}
+}
+
+// checkSessionNames finds sessions that point at a worktree but are not
+// named the way naming.GetSessionName would name it. twt still pairs them
+// through @workdir, but other tools going by name will not.
+func checkSessionNames(entries []workspace.Entry, sessions []tmux.Session) Check {
+	byPath := make(map[string]workspace.Entry)
+	for _, e := range entries {
+		byPath[e.Path] = e
+	}
+
+	var mismatched []string
+	for _, s := range sessions {
+		if s.Group != "" && s.Group != s.Name {
+			continue // grouped with the session of the worktree, e.g. by `twt attach --grouped`
+		}
+		e, ok := byPath[s.Workdir]
+		if expected := naming.GetSessionName(e.RepoName, e.Slug); ok && expected != s.Name {
+			mismatched = append(mismatched, fmt.Sprintf("%s (expected %s)", s.Name, expected))
+		}
+	}
+
+	if len(mismatched) > 0 {
+		return Check{"session names", Warn, "only matched by @workdir: " + strings.Join(mismatched, ", ")}
+	}
+	return Check{"session names", Pass, "all sessions match their worktree"}
+}
+
+// checkDuplicateNames finds worktrees of different repositories that map to
+// the same session name, e.g. two checkouts both called "api".
+func checkDuplicateNames(entries []workspace.Entry) Check {
+	paths := make(map[string][]string)
+	for _, e := range entries {
+		paths[e.SessionName] = append(paths[e.SessionName], e.Path)
+	}
+
+	var duplicates []string
+	for name, p := range paths {
+		if len(p) > 1 {
+			duplicates = append(duplicates, fmt.Sprintf("%s (%s)", name, strings.Join(p, ", ")))
+		}
+	}
+	sort.Strings(duplicates)
+
+	if len(duplicates) > 0 {
+		return Check{"duplicate session names", Fail, strings.Join(duplicates, "; ")}
+	}
+	return Check{"duplicate session names", Pass, "none"}
+}
+
+func checkPrunable(repos []string) Check {
+	var prunable []string
+	for _, repo := range repos {
+		wts, err := git.ListAllWorktrees(repo)
+		if err != nil {
+			continue
+		}
+		for _, wt := range wts {
+			if wt.Prunable {
+				prunable = append(prunable, wt.Path)
+			}
+		}
+	}
+
+	if len(prunable) > 0 {
+		return Check{"prunable worktrees", Warn,
+			"run `git worktree prune` for: " + strings.Join(prunable, ", ")}
+	}
+	return Check{"prunable worktrees", Pass, "none"}
+}
+
+// older reports whether major.minor is below min.
+func older(major, minor int, min [2]int) bool {
+	return major < min[0] || (major == min[0] && minor < min[1])
+}
@@ -0,0 +1,155 @@
+package doctor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func TestCheckVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  func() Check
+		argv   []string
+		output string
+		code   int
+		want   Status
+	}{
+		{"tmux", checkTmux, []string{"tmux", "-V"}, "tmux 3.4\n", 0, Pass},
+		{"tmux", checkTmux, []string{"tmux", "-V"}, "tmux 3.2a\n", 0, Pass},
+		{"tmux", checkTmux, []string{"tmux", "-V"}, "tmux 3.1c\n", 0, Warn},
+		{"tmux", checkTmux, []string{"tmux", "-V"}, "tmux next-3.5\n", 0, Pass}, // unparsed versions pass
+		{"tmux", checkTmux, []string{"tmux", "-V"}, "", 127, Fail},
+		{"git", checkGit, []string{"git", "--version"}, "git version 2.43.0\n", 0, Pass},
+		{"git", checkGit, []string{"git", "--version"}, "git version 2.31.1\n", 0, Pass},
+		{"git", checkGit, []string{"git", "--version"}, "git version 2.30.9\n", 0, Warn},
+		{"git", checkGit, []string{"git", "--version"}, "git version 1.9.5\n", 0, Warn},
+		{"git", checkGit, []string{"git", "--version"}, "", 127, Fail},
+	}
+
+	oldGit, oldTmux := git.Runner, tmux.Runner
+	t.Cleanup(func() { git.Runner, tmux.Runner = oldGit, oldTmux })
+	for _, tt := range tests {
+		fake := runner.NewFake(runner.Step{Argv: tt.argv, Stdout: tt.output, ExitCode: tt.code})
+		git.Runner, tmux.Runner = fake, fake
+
+		c := tt.check()
+		if c.Name != tt.name || c.Status != tt.want {
+			t.Errorf("%s with %q: got %s %q, want %s", tt.name, tt.output, c.Status, c.Message, tt.want)
+		}
+	}
+}
+
+func TestCheckWorkdirOptions(t *testing.T) {
+	// Any tmux command fails, so the check must get by with the listed sessions
+	old := tmux.Runner
+	tmux.Runner = runner.NewFake()
+	t.Cleanup(func() { tmux.Runner = old })
+
+	tests := []struct {
+		name     string
+		sessions []tmux.Session
+		want     Status
+		message  string
+	}{
+		{"none", nil, Pass, "0 sessions"},
+		{"all set", []tmux.Session{
+			{Name: "api_auth", Workdir: "/src/api/.worktrees/auth", HasWorkdir: true},
+			{Name: "api_main", Workdir: "/src/api", HasWorkdir: true},
+		}, Pass, "2 sessions"},
+		{"missing", []tmux.Session{
+			{Name: "api_auth", Workdir: "/src/api/.worktrees/auth", HasWorkdir: true},
+			{Name: "scratch", Workdir: "/tmp"},
+			{Name: "notes", Workdir: "/home/me"},
+		}, Warn, "no @workdir set: scratch, notes"},
+	}
+	for _, tt := range tests {
+		c := checkWorkdirOptions(tt.sessions)
+		if c.Status != tt.want || c.Message != tt.message {
+			t.Errorf("%s: got %s %q, want %s %q", tt.name, c.Status, c.Message, tt.want, tt.message)
+		}
+	}
+}
+
+func TestCheckSessionNames(t *testing.T) {
+	entries := []workspace.Entry{
+		{RepoName: "api", Slug: "auth", Path: "/src/api/.worktrees/auth"},
+		{RepoName: "web.app", Slug: "main", Path: "/src/web.app"},
+	}
+	tests := []struct {
+		name     string
+		sessions []tmux.Session
+		want     Status
+		mentions string
+	}{
+		{"none", nil, Pass, ""},
+		{"matching", []tmux.Session{
+			{Name: "api_auth", Workdir: "/src/api/.worktrees/auth"},
+			{Name: "web-app_main", Workdir: "/src/web.app"},
+		}, Pass, ""},
+		{"renamed", []tmux.Session{
+			{Name: "login", Workdir: "/src/api/.worktrees/auth"},
+		}, Warn, "login (expected api_auth)"},
+		{"grouped", []tmux.Session{
+			{Name: "api_auth", Workdir: "/src/api/.worktrees/auth", Group: "api_auth"},
+			{Name: "api_auth+1", Workdir: "/src/api/.worktrees/auth", Group: "api_auth"},
+		}, Pass, ""},
+		{"elsewhere", []tmux.Session{
+			{Name: "scratch", Workdir: "/tmp"},
+		}, Pass, ""},
+	}
+	for _, tt := range tests {
+		c := checkSessionNames(entries, tt.sessions)
+		if c.Status != tt.want || !strings.Contains(c.Message, tt.mentions) {
+			t.Errorf("%s: got %s %q, want %s mentioning %q", tt.name, c.Status, c.Message, tt.want, tt.mentions)
+		}
+	}
+}
+
+func TestCheckDuplicateNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []workspace.Entry
+		want    Status
+		message string
+	}{
+		{"none", nil, Pass, "none"},
+		{"distinct", []workspace.Entry{
+			{SessionName: "api_main", Path: "/src/api"},
+			{SessionName: "api_auth", Path: "/src/api/.worktrees/auth"},
+		}, Pass, "none"},
+		{"clash", []workspace.Entry{
+			{SessionName: "api_main", Path: "/src/api"},
+			{SessionName: "api_main", Path: "/work/api"},
+			{SessionName: "web_main", Path: "/src/web"},
+		}, Fail, "api_main (/src/api, /work/api)"},
+	}
+	for _, tt := range tests {
+		c := checkDuplicateNames(tt.entries)
+		if c.Status != tt.want || c.Message != tt.message {
+			t.Errorf("%s: got %s %q, want %s %q", tt.name, c.Status, c.Message, tt.want, tt.message)
+		}
+	}
+}
+
+func TestOlder(t *testing.T) {
+	tests := []struct {
+		major, minor int
+		want         bool
+	}{
+		{3, 1, true},
+		{3, 2, false},
+		{3, 10, false},
+		{2, 9, true},
+		{4, 0, false},
+	}
+	for _, tt := range tests {
+		if got := older(tt.major, tt.minor, [2]int{3, 2}); got != tt.want {
+			t.Errorf("older(%d, %d, 3.2) = %v, want %v", tt.major, tt.minor, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package editor opens worktrees in the user's editor.
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/shell"
+)
+
+// PathPlaceholder is replaced by the worktree path in editor templates.
+// Templates without it get the path appended.
+const PathPlaceholder = "{path}"
+
+// ErrNoEditor is returned when neither the config nor the environment
+// names an editor.
+var ErrNoEditor = errors.New(`no editor configured: set "editor" in the config, $VISUAL or $EDITOR`)
+
+// Template returns the editor template for a repository. The first match
+// wins: a per-project entry in "editors" (keyed by repo name, then path),
+// the global "editor", $VISUAL, then $EDITOR.
+func Template(cfg *config.Config, repoName, repoPath string) (string, error) {
+	if template, ok := config.ForRepo(cfg.Editors, repoName, repoPath); ok {
+		return template, nil
+	}
+
+	for _, template := range []string{cfg.Editor, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if strings.TrimSpace(template) != "" {
+			return template, nil
+		}
+	}
+	return "", ErrNoEditor
+}
+
+// Expand turns a template into a shell command that opens path.
+func Expand(template, path string) string {
+	quoted := shell.Quote(path)
+	if strings.Contains(template, PathPlaceholder) {
+		return strings.ReplaceAll(template, PathPlaceholder, quoted)
+	}
+	return template + " " + quoted
+}
+
+// Command builds the command that opens path in the repository's editor.
+// It runs through the shell so templates may carry flags and pipes; the
+// caller wires up stdio, since terminal editors need the terminal.
+func Command(cfg *config.Config, repoName, repoPath, path string) (*exec.Cmd, error) {
+	template, err := Template(cfg, repoName, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sh", "-c", Expand(template, path))
+	cmd.Dir = path
+	return cmd, nil
+}
@@ -0,0 +1,67 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		template string
+		path     string
+		want     string
+	}{
+		{"code {path}", "/src/api", "code '/src/api'"},
+		{"nvim", "/src/api", "nvim '/src/api'"},
+		{"idea {path} --wait", "/src/it's", `idea '/src/it'\''s' --wait`},
+		{"cd {path} && zed {path}", "/a b", "cd '/a b' && zed '/a b'"},
+	}
+
+	for _, tt := range tests {
+		if got := Expand(tt.template, tt.path); got != tt.want {
+			t.Errorf("Expand(%q, %q) = %q, want %q", tt.template, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTemplatePrecedence(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vi")
+
+	cfg := &config.Config{
+		Editor: "code {path}",
+		Editors: map[string]string{
+			"api":       "nvim {path}",
+			"/src/blog": "zed",
+		},
+	}
+
+	tests := []struct {
+		repoName string
+		repoPath string
+		want     string
+	}{
+		{"api", "/src/api", "nvim {path}"},
+		{"web", "/src/blog", "zed"},
+		{"other", "/src/other", "code {path}"},
+		// The name wins over the path
+		{"api", "/src/blog", "nvim {path}"},
+	}
+	for _, tt := range tests {
+		got, err := Template(cfg, tt.repoName, tt.repoPath)
+		if err != nil || got != tt.want {
+			t.Errorf("Template(%q, %q) = %q, %v; want %q", tt.repoName, tt.repoPath, got, err, tt.want)
+		}
+	}
+
+	got, err := Template(&config.Config{}, "api", "/src/api")
+	if err != nil || got != "vi" {
+		t.Errorf("Template without config = %q, %v; want $EDITOR", got, err)
+	}
+
+	t.Setenv("EDITOR", "")
+	if _, err := Template(&config.Config{}, "api", "/src/api"); err != ErrNoEditor {
+		t.Errorf("Template with nothing set: err = %v, want ErrNoEditor", err)
+	}
+}
@@ -2,7 +2,8 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -15,18 +16,33 @@ type Worktree struct {
 	Prunable bool
 }
 
-// ListWorktrees returns a list of worktrees for the given repo root.
-// It parses `git worktree list --porcelain`.
+// ListWorktrees returns a list of worktrees for the given repo root,
+// skipping prunable ones whose directory no longer exists.
 func ListWorktrees(repoRoot string) ([]Worktree, error) {
-	cmd := exec.Command("git", "worktree", "list", "--porcelain")
-	cmd.Dir = repoRoot
-	output, err := cmd.Output()
+	all, err := ListAllWorktrees(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []Worktree
+	for _, wt := range all {
+		if !wt.Prunable {
+			worktrees = append(worktrees, wt)
+		}
+	}
+	return worktrees, nil
+}
+
+// ListAllWorktrees returns every worktree registered in the repository,
+// including prunable ones. It parses `git worktree list --porcelain`.
+func ListAllWorktrees(repoRoot string) ([]Worktree, error) {
+	res, err := run(repoRoot, "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("git worktree list failed: %w", err)
 	}
 
 	var worktrees []Worktree
-	blocks := strings.Split(string(output), "\n\n")
+	blocks := strings.Split(string(res.Stdout), "\n\n")
 
 	for _, block := range blocks {
 		if strings.TrimSpace(block) == "" {
@@ -43,12 +59,13 @@ func ListWorktrees(repoRoot string) ([]Worktree, error) {
 				wt.Branch = strings.TrimPrefix(line, "branch refs/heads/") // Strip refs/heads/
 			} else if strings.HasPrefix(line, "HEAD ") {
 				wt.Head = strings.TrimPrefix(line, "HEAD ")
-			} else if line == "prunable" {
+			} else if line == "prunable" || strings.HasPrefix(line, "prunable ") {
+				// Newer git versions append the reason
 				wt.Prunable = true
 			}
 		}
 
-		if wt.Path != "" && !wt.Prunable {
+		if wt.Path != "" {
 			// Determine IsMain based on branch name (using same logic as TS)
 			// In TS: isMain: !branch.startsWith('task/')
 			wt.IsMain = !strings.HasPrefix(wt.Branch, "task/")
@@ -61,11 +78,173 @@ func ListWorktrees(repoRoot string) ([]Worktree, error) {
 
 // GetRepoRoot returns the absolute path to the git repository root.
 func GetRepoRoot(path string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
-	cmd.Dir = path
-	output, err := cmd.Output()
+	res, err := run(path, "rev-parse", "--show-toplevel")
 	if err != nil {
 		return "", fmt.Errorf("not a git repository: %w", err)
 	}
-	return strings.TrimSpace(string(output)), nil
+	return res.Output(), nil
+}
+
+// GetBaseBranch determines the start point for new task branches.
+// origin/main is preferred so tasks start from the latest remote state,
+// falling back to the local main branch.
+func GetBaseBranch(repoRoot string) (string, error) {
+	for _, ref := range []string{"origin/main", "main"} {
+		if RefExists(repoRoot, ref) {
+			return ref, nil
+		}
+	}
+	return "", fmt.Errorf("no main branch found (origin/main or main)")
+}
+
+// RefExists reports whether ref resolves to a commit in the repository.
+func RefExists(repoRoot, ref string) bool {
+	_, err := run(repoRoot, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// BranchExists reports whether a local branch exists.
+func BranchExists(repoRoot, branch string) bool {
+	_, err := run(repoRoot, "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// AddWorktree creates .worktrees/<slug> on a new task/<slug> branch
+// starting at base, and returns the worktree path.
+// The branch is configured to push to origin/task/<slug> even though the
+// remote branch does not exist yet.
+func AddWorktree(repoRoot, slug, base string) (string, error) {
+	worktreesDir := filepath.Join(repoRoot, ".worktrees")
+	if err := os.MkdirAll(worktreesDir, 0755); err != nil {
+		return "", err
+	}
+
+	worktreePath := filepath.Join(worktreesDir, slug)
+	branchName := "task/" + slug
+	args := []string{"worktree", "add", worktreePath, "-b", branchName}
+	if base != "" {
+		args = append(args, base)
+	}
+
+	if res, err := run(repoRoot, args...); err != nil {
+		return "", fmt.Errorf("git worktree add failed: %s", res.Message())
+	}
+
+	upstream := [][2]string{
+		{"branch." + branchName + ".remote", "origin"},
+		{"branch." + branchName + ".merge", "refs/heads/" + branchName},
+	}
+	for _, kv := range upstream {
+		if _, err := run(repoRoot, "config", kv[0], kv[1]); err != nil {
+			return worktreePath, fmt.Errorf("git config %s failed: %w", kv[0], err)
+		}
+	}
+
+	return worktreePath, nil
+}
+
+// RemoveWorktree removes a worktree checkout.
+// Without force, git refuses to remove a worktree with local changes.
+func RemoveWorktree(repoRoot, worktreePath string, force bool) error {
+	args := []string{"worktree", "remove", worktreePath}
+	if force {
+		args = append(args, "--force")
+	}
+
+	if res, err := run(repoRoot, args...); err != nil {
+		return fmt.Errorf("git worktree remove failed: %s", res.Message())
+	}
+	return nil
+}
+
+// MoveWorktree moves a linked worktree to newPath.
+func MoveWorktree(repoRoot, worktreePath, newPath string) error {
+	if res, err := run(repoRoot, "worktree", "move", worktreePath, newPath); err != nil {
+		return fmt.Errorf("git worktree move failed: %s", res.Message())
+	}
+	return nil
+}
+
+// RenameBranch renames a local branch, including one checked out in a
+// worktree. An upstream set up by AddWorktree for the old name is pointed
+// at the new name, since that remote branch was never pushed under it.
+func RenameBranch(repoRoot, oldName, newName string) error {
+	if res, err := run(repoRoot, "branch", "-m", oldName, newName); err != nil {
+		return fmt.Errorf("git branch -m failed: %s", res.Message())
+	}
+
+	key := "branch." + newName + ".merge"
+	if res, err := run(repoRoot, "config", "--get", key); err == nil && res.Output() == "refs/heads/"+oldName {
+		if _, err := run(repoRoot, "config", key, "refs/heads/"+newName); err != nil {
+			return fmt.Errorf("git config %s failed: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// GetMainRepoRoot returns the root of the main working tree, even when path
+// is inside a linked worktree (where GetRepoRoot returns the worktree itself).
+func GetMainRepoRoot(path string) (string, error) {
+	res, err := run(path, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	return filepath.Dir(res.Output()), nil
+}
+
+// CurrentBranch returns the branch checked out at path, or "" when HEAD is
+// detached.
+func CurrentBranch(path string) (string, error) {
+	res, err := run(path, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		if res.ExitCode == 1 {
+			return "", nil
+		}
+		return "", fmt.Errorf("git symbolic-ref failed: %s", res.Message())
+	}
+	return res.Output(), nil
+}
+
+// UnmergedCommits counts commits on branch that are neither on any remote
+// branch nor reachable from base. Those commits would be lost if the branch
+// were deleted.
+func UnmergedCommits(repoRoot, branch, base string) (int, error) {
+	args := []string{"rev-list", "--count", "refs/heads/" + branch, "--not", "--remotes"}
+	if base != "" {
+		args = append(args, base)
+	}
+
+	res, err := run(repoRoot, args...)
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list failed: %w", err)
+	}
+
+	var count int
+	if _, err := fmt.Sscanf(res.Output(), "%d", &count); err != nil {
+		return 0, fmt.Errorf("unexpected rev-list output: %q", res.Stdout)
+	}
+	return count, nil
+}
+
+// DeleteBranch deletes a local branch. Without force, git refuses to delete
+// a branch that is not merged.
+func DeleteBranch(repoRoot, branch string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+
+	if res, err := run(repoRoot, "branch", flag, branch); err != nil {
+		return fmt.Errorf("git branch %s failed: %s", flag, res.Message())
+	}
+	return nil
+}
+
+// Version returns the output of `git --version`, e.g. "git version 2.43.0".
+func Version() (string, error) {
+	res, err := run("", "--version")
+	if err != nil {
+		return "", err
+	}
+	return res.Output(), nil
 }
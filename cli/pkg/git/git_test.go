@@ -0,0 +1,94 @@
+package git
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+)
+
+func fakeGit(t *testing.T, steps ...runner.Step) *runner.Fake {
+	t.Helper()
+	fake := runner.NewFake(steps...)
+	old := Runner
+	Runner = fake
+	t.Cleanup(func() { Runner = old })
+	return fake
+}
+
+func TestListWorktrees(t *testing.T) {
+	porcelain := `worktree /src/api
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /src/api/.worktrees/auth
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/task/auth
+
+worktree /src/api/.worktrees/gone
+HEAD 3333333333333333333333333333333333333333
+branch refs/heads/task/gone
+prunable gitdir file points to non-existent location
+
+`
+	fakeGit(t, runner.Step{Argv: []string{"git", "worktree", "list", "--porcelain"}, Stdout: porcelain, Repeat: true})
+
+	all, err := ListAllWorktrees("/src/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || !all[2].Prunable {
+		t.Fatalf("ListAllWorktrees = %+v", all)
+	}
+
+	wts, err := ListWorktrees("/src/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wts) != 2 {
+		t.Fatalf("ListWorktrees returned %d worktrees, expected 2", len(wts))
+	}
+	if !wts[0].IsMain || wts[1].IsMain || wts[1].Branch != "task/auth" {
+		t.Errorf("unexpected worktrees: %+v", wts)
+	}
+}
+
+func TestAddWorktree(t *testing.T) {
+	repo := t.TempDir()
+	fake := fakeGit(t,
+		runner.Step{Argv: []string{"git", "worktree", "add", "*"}},
+		runner.Step{Argv: []string{"git", "config", "*"}, Repeat: true},
+	)
+
+	path, err := AddWorktree(repo, "auth", "origin/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"git", "worktree", "add", path, "-b", "task/auth", "origin/main"},
+		{"git", "config", "branch.task/auth.remote", "origin"},
+		{"git", "config", "branch.task/auth.merge", "refs/heads/task/auth"},
+	}
+	if got := fake.Argvs(); !slices.EqualFunc(got, expected, slices.Equal) {
+		t.Errorf("ran %q\nexpected %q", got, expected)
+	}
+	for _, rec := range fake.Records() {
+		if rec.Command.Dir != repo {
+			t.Errorf("%s ran in %q, expected the repo root", rec.Command, rec.Command.Dir)
+		}
+	}
+}
+
+func TestAddWorktreeFailure(t *testing.T) {
+	fakeGit(t, runner.Step{
+		Argv:     []string{"git", "worktree", "add", "*"},
+		Stderr:   "fatal: a branch named 'task/auth' already exists\n",
+		ExitCode: 128,
+	})
+
+	_, err := AddWorktree(t.TempDir(), "auth", "")
+	if err == nil || err.Error() != "git worktree add failed: fatal: a branch named 'task/auth' already exists" {
+		t.Errorf("err = %v", err)
+	}
+}
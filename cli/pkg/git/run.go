@@ -0,0 +1,27 @@
+package git
+
+import (
+	"context"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+)
+
+// Runner executes git commands. Tests replace it with a runner.Fake.
+var Runner runner.Runner = runner.Exec{}
+
+// Timeout bounds every git command, so a hung repository (e.g. on an
+// unreachable network mount) cannot freeze the picker.
+var Timeout = 30 * time.Second
+
+// run runs git in dir.
+func run(dir string, args ...string) (runner.Result, error) {
+	return runContext(context.Background(), dir, args...)
+}
+
+// runContext runs git in dir, giving up when ctx is done or Timeout passes.
+func runContext(ctx context.Context, dir string, args ...string) (runner.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	return Runner.Run(ctx, runner.Command{Name: "git", Args: args, Dir: dir})
+}
@@ -3,7 +3,6 @@ package git
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -35,15 +34,13 @@ func GetStatus(repoPath string) (*GitStatus, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain")
-	cmd.Dir = repoPath
-	output, err := cmd.Output()
+	res, err := runContext(ctx, repoPath, "status", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("git status failed: %w", err)
 	}
 
 	status := &GitStatus{}
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(string(res.Stdout), "\n")
 
 	for _, line := range lines {
 		if len(line) < 2 {
@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/ports"
+	"github.com/kargnas/tmux-worktree-tui/pkg/shell"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Worktree is what "env" templates are expanded for.
+type Worktree struct {
+	Repo     string // {repo}
+	RepoPath string // main working tree, for "repo_env" and "repo_layouts"
+	Slug     string // {slug}
+	Branch   string // {branch}, empty when HEAD is detached
+	Path     string // {path}
+}
+
+// WorktreeAt describes the worktree at path, asking git for its
+// repository and branch.
+func WorktreeAt(path string) Worktree {
+	repoPath, err := git.GetMainRepoRoot(path)
+	if err != nil {
+		repoPath = path
+	}
+	repo := naming.GetRepoName(repoPath)
+	branch, _ := git.CurrentBranch(path)
+
+	return Worktree{
+		Repo:     repo,
+		RepoPath: repoPath,
+		Slug:     naming.GetSlugFromWorktree(path, repo, !strings.HasPrefix(branch, "task/")),
+		Branch:   branch,
+		Path:     path,
+	}
+}
+
+// Var is an environment variable of a session.
+type Var struct {
+	Name, Value string
+}
+
+// Env returns the variables for a new session of w, sorted by name: the
+// worktree's ports if "ports" is set (reserving them on first use), "env",
+// then the repository's "repo_env" entry (keyed by name or path) on top,
+// templates expanded.
+func Env(cfg *config.Config, w Worktree) ([]Var, error) {
+	merged := make(map[string]string, len(cfg.Env))
+	if cfg.Ports != nil && cfg.Ports.Count > 0 {
+		block, err := ports.Reserve(w.Path, *cfg.Ports)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve ports: %w", err)
+		}
+		for i, name := range ports.EnvNames(len(block)) {
+			merged[name] = strconv.Itoa(block[i])
+		}
+	}
+	for name, value := range cfg.Env {
+		merged[name] = value
+	}
+	if env, ok := config.ForRepo(cfg.RepoEnv, w.Repo, w.RepoPath); ok {
+		for name, value := range env {
+			merged[name] = value
+		}
+	}
+
+	vars := make([]Var, 0, len(merged))
+	for name, value := range merged {
+		if !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid environment variable name %q", name)
+		}
+		vars = append(vars, Var{Name: name, Value: ExpandEnv(value, w)})
+	}
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Name < vars[j].Name })
+	return vars, nil
+}
+
+// ExpandEnv replaces {repo}, {slug}, {branch} and {path} in value.
+func ExpandEnv(value string, w Worktree) string {
+	return strings.NewReplacer(
+		"{repo}", w.Repo,
+		"{slug}", w.Slug,
+		"{branch}", w.Branch,
+		"{path}", w.Path,
+	).Replace(value)
+}
+
+// ExportCommand returns the shell command that exports vars. It starts
+// with a space so shells ignoring such lines keep it out of the history.
+func ExportCommand(vars []Var) string {
+	var b strings.Builder
+	b.WriteString(" export")
+	for _, v := range vars {
+		b.WriteString(" " + v.Name + "=" + shell.Quote(v.Value))
+	}
+	return b.String()
+}
+
+// SetEnv sets vars in the session environment, for every pane created
+// from now on, and exports them in the shell of paneID, which already runs.
+func SetEnv(sessionName, paneID string, vars []Var) error {
+	if len(vars) == 0 {
+		return nil
+	}
+	for _, v := range vars {
+		if err := tmux.SetEnvironment(sessionName, v.Name, v.Value); err != nil {
+			return err
+		}
+	}
+	return tmux.SendKeys(paneID, ExportCommand(vars))
+}
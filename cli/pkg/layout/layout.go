@@ -0,0 +1,153 @@
+// Package layout creates tmux sessions with the windows and panes
+// described in the config.
+package layout
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// Select returns the layout for a repository: the one called name if it
+// is set, else the repository's entry in "repo_layouts" (keyed by name or
+// path), else "layout". It returns nil when no layout applies.
+func Select(cfg *config.Config, name, repoName, repoPath string) (*config.Layout, error) {
+	if name == "" {
+		name, _ = config.ForRepo(cfg.RepoLayouts, repoName, repoPath)
+	}
+	if name == "" {
+		name = cfg.Layout
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	l, ok := cfg.Layouts[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown layout %q", name)
+	}
+	if err := Validate(l); err != nil {
+		return nil, fmt.Errorf("layout %q: %w", name, err)
+	}
+	return &l, nil
+}
+
+// Validate reports the first problem in l that would only surface halfway
+// through creating a session.
+func Validate(l config.Layout) error {
+	for i, w := range l.Windows {
+		for j, p := range w.Panes {
+			if p.Split != "" && p.Split != "vertical" && p.Split != "horizontal" {
+				return fmt.Errorf("window %d pane %d: split must be vertical or horizontal, not %q", i+1, j+1, p.Split)
+			}
+		}
+	}
+	return nil
+}
+
+// CreateSession creates a detached session for the worktree at path,
+// sets env in it and builds the windows of l. A nil layout gives one bare
+// window.
+func CreateSession(sessionName, path string, l *config.Layout, env []Var) error {
+	if l == nil || len(l.Windows) == 0 {
+		if len(env) == 0 {
+			return tmux.CreateSession(sessionName, path)
+		}
+		l = &config.Layout{Windows: []config.Window{{}}}
+	}
+
+	if err := build(sessionName, path, l, env); err != nil {
+		// Don't leave a half-built session behind
+		if tmux.HasSession(sessionName) {
+			_ = tmux.KillSession(sessionName)
+		}
+		return err
+	}
+	return nil
+}
+
+func build(sessionName, path string, l *config.Layout, env []Var) error {
+	for i, w := range l.Windows {
+		panes := w.Panes
+		if len(panes) == 0 {
+			panes = []config.Pane{{Dir: w.Dir, Command: w.Command}}
+		}
+
+		var paneID string
+		var err error
+		if i == 0 {
+			paneID, err = tmux.CreateSessionWindow(sessionName, path, w.Name, resolveDir(path, panes[0].Dir))
+		} else {
+			paneID, err = tmux.NewWindow(sessionName, w.Name, resolveDir(path, panes[0].Dir), "")
+		}
+		if err != nil {
+			return fmt.Errorf("window %q: %w", w.Name, err)
+		}
+		if i == 0 {
+			// Before any other pane, so they all start with env
+			if err := SetEnv(sessionName, paneID, env); err != nil {
+				return err
+			}
+		}
+
+		ids := []string{paneID}
+		for _, p := range panes[1:] {
+			id, err := tmux.SplitWindow(ids[len(ids)-1], tmux.Split{
+				Cwd:        resolveDir(path, p.Dir),
+				Horizontal: p.Split == "horizontal",
+				Size:       p.Size,
+			})
+			if err != nil {
+				return fmt.Errorf("window %q: %w", w.Name, err)
+			}
+			ids = append(ids, id)
+		}
+
+		if w.Layout != "" {
+			if err := tmux.SelectLayout(paneID, w.Layout); err != nil {
+				return fmt.Errorf("window %q: %w", w.Name, err)
+			}
+		}
+
+		// Commands are typed into the shell so the pane survives them
+		for j, p := range panes {
+			if p.Command == "" {
+				continue
+			}
+			if err := tmux.SendKeys(ids[j], p.Command); err != nil {
+				return fmt.Errorf("window %q: %w", w.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CreateSessionFor creates the session of the worktree at path with the
+// layout and environment its repository selects.
+func CreateSessionFor(sessionName, path string) error {
+	cfg := workspace.LoadConfig()
+	w := WorktreeAt(path)
+
+	l, err := Select(cfg, "", w.Repo, w.RepoPath)
+	if err != nil {
+		return err
+	}
+	env, err := Env(cfg, w)
+	if err != nil {
+		return err
+	}
+	return CreateSession(sessionName, path, l, env)
+}
+
+// resolveDir returns dir relative to the worktree at path.
+func resolveDir(path, dir string) string {
+	dir = discovery.ExpandPath(dir)
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(path, dir)
+}
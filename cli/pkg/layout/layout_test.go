@@ -0,0 +1,123 @@
+package layout
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func TestSelect(t *testing.T) {
+	cfg := &config.Config{
+		Layouts: map[string]config.Layout{
+			"dev":  {Windows: []config.Window{{Name: "editor"}}},
+			"mini": {},
+		},
+		Layout:      "mini",
+		RepoLayouts: map[string]string{"api": "dev"},
+	}
+
+	tests := []struct {
+		name, repoName string
+		want           int // windows of the selected layout
+		wantErr        bool
+	}{
+		{"", "api", 1, false},
+		{"", "web", 0, false},
+		{"mini", "api", 0, false},
+		{"nope", "api", 0, true},
+	}
+	for _, tt := range tests {
+		l, err := Select(cfg, tt.name, tt.repoName, "/src/"+tt.repoName)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Select(%q, %q) err = %v", tt.name, tt.repoName, err)
+			continue
+		}
+		if err == nil && len(l.Windows) != tt.want {
+			t.Errorf("Select(%q, %q) has %d windows, want %d", tt.name, tt.repoName, len(l.Windows), tt.want)
+		}
+	}
+
+	if l, err := Select(&config.Config{}, "", "api", "/src/api"); l != nil || err != nil {
+		t.Errorf("Select without layouts = %v, %v; want nil", l, err)
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	fake := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "new-session", "-d", "-s", "api_auth", "-c", "/src/auth", "-P", "-F", "#{pane_id}", "-n", "editor"}, Stdout: "%1\n"},
+		runner.Step{Argv: []string{"tmux", "set-option", "-t", "api_auth", "@workdir", "/src/auth"}},
+		runner.Step{Argv: []string{"tmux", "set-environment", "-t", "=api_auth:", "TASK_SLUG", "auth"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "-l", "--", " export TASK_SLUG='auth'"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "Enter"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "-l", "--", "nvim ."}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "Enter"}},
+		runner.Step{Argv: []string{"tmux", "new-window", "-d", "-t", "=api_auth:", "-P", "-F", "#{pane_id}", "-n", "server", "-c", "/src/auth/web"}, Stdout: "%2\n"},
+		runner.Step{Argv: []string{"tmux", "split-window", "-d", "-t", "%2", "-P", "-F", "#{pane_id}", "-h", "-l", "30%", "-c", "/tmp"}, Stdout: "%3\n"},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%2", "-l", "--", "npm run dev"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%2", "Enter"}},
+	)
+	old := tmux.Runner
+	tmux.Runner = fake
+	t.Cleanup(func() { tmux.Runner = old })
+
+	l := &config.Layout{Windows: []config.Window{
+		{Name: "editor", Command: "nvim ."},
+		{Name: "server", Panes: []config.Pane{
+			{Dir: "web", Command: "npm run dev"},
+			{Dir: "/tmp", Split: "horizontal", Size: "30%"},
+		}},
+	}}
+	if err := CreateSession("api_auth", "/src/auth", l, []Var{{"TASK_SLUG", "auth"}}); err != nil {
+		t.Fatalf("%v; ran %q", err, fake.Argvs())
+	}
+	if unused := fake.Unused(); len(unused) != 0 {
+		t.Errorf("commands not run: %v", unused)
+	}
+	for _, argv := range fake.Argvs() {
+		if slices.Contains(argv, "kill-session") {
+			t.Errorf("session was killed: %q", argv)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	cfg := &config.Config{
+		Env: map[string]string{
+			"WORKTREE_SLUG":        "{slug}",
+			"COMPOSE_PROJECT_NAME": "{repo}_{slug}",
+			"DB_NAME":              "app_{slug}",
+		},
+		RepoEnv: map[string]map[string]string{
+			"api": {"DB_NAME": "api_{slug}", "TASK_BRANCH": "{branch}", "ROOT": "{path}"},
+			"web": {"DB_NAME": "web"},
+		},
+	}
+	w := Worktree{Repo: "api", RepoPath: "/src/api", Slug: "auth", Branch: "task/auth", Path: "/src/api/.worktrees/it's"}
+
+	vars, err := Env(cfg, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Var{
+		{"COMPOSE_PROJECT_NAME", "api_auth"},
+		{"DB_NAME", "api_auth"},
+		{"ROOT", "/src/api/.worktrees/it's"},
+		{"TASK_BRANCH", "task/auth"},
+		{"WORKTREE_SLUG", "auth"},
+	}
+	if !slices.Equal(vars, want) {
+		t.Errorf("Env = %v, want %v", vars, want)
+	}
+
+	wantCmd := ` export COMPOSE_PROJECT_NAME='api_auth' DB_NAME='api_auth' ROOT='/src/api/.worktrees/it'\''s' TASK_BRANCH='task/auth' WORKTREE_SLUG='auth'`
+	if got := ExportCommand(vars); got != wantCmd {
+		t.Errorf("ExportCommand = %s, want %s", got, wantCmd)
+	}
+
+	if _, err := Env(&config.Config{Env: map[string]string{"NOT-VALID": "x"}}, w); err == nil {
+		t.Error("Env accepted an invalid variable name")
+	}
+}
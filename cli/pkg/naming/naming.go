@@ -1,19 +1,28 @@
 package naming
 
 import (
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// MaxSlugLength is the longest slug accepted for new tasks.
+const MaxSlugLength = 32
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
 // GetRepoName returns the basename of the repository root directory.
 func GetRepoName(repoRoot string) string {
 	return filepath.Base(repoRoot)
 }
 
 // GetSlugFromSessionName extracts the slug from a tmux session name.
-// Format: {repoName}_{slug}
+// Format: {repoName}_{slug}, with the repository name sanitized. The slug
+// comes back sanitized too; use the session's @workdir to find the original.
 func GetSlugFromSessionName(sessionName, repoName string) string {
-	prefix := repoName + "_"
+	prefix := SanitizeSessionName(repoName) + "_"
 	if !strings.HasPrefix(sessionName, prefix) {
 		// If prefix doesn't match, it might not be a managed session,
 		// but if we force it, return raw name or handle as needed.
@@ -49,9 +58,19 @@ func GetSlugFromWorktree(worktreePath, repoName string, isMain bool) string {
 
 // GetSessionName constructs the tmux session name.
 func GetSessionName(repoName, slug string) string {
-	return repoName + "_" + slug
+	return SanitizeSessionName(repoName) + "_" + SanitizeSessionName(slug)
+}
+
+// SanitizeSessionName replaces the characters tmux does not allow in
+// session names ("." and ":") with "-", like the extension's
+// sanitizeSessionName. tmux would otherwise rewrite them, and the session
+// could no longer be found by the name it was created with.
+func SanitizeSessionName(name string) string {
+	return sessionNameReplacer.Replace(name)
 }
 
+var sessionNameReplacer = strings.NewReplacer(".", "-", ":", "-")
+
 // IsMainBranch determines if a branch is considered "main".
 // Logic: If it starts with "task/", it is NOT main.
 func IsMainBranch(branch string) bool {
@@ -82,3 +101,33 @@ func IsRoot(slug, repoName string, worktreePath string, isMain bool) bool {
 
 	return false
 }
+
+// NormalizeSlug lowercases a task slug and replaces whitespace with "-".
+func NormalizeSlug(input string) string {
+	return strings.Join(strings.Fields(strings.ToLower(input)), "-")
+}
+
+// ValidateSlug checks a task slug against the same rules as the extension:
+// lowercase letters, numbers and hyphens, at most MaxSlugLength characters.
+func ValidateSlug(slug string) error {
+	if slug == "" {
+		return fmt.Errorf("slug is required")
+	}
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("slug must contain only lowercase letters, numbers, and hyphens")
+	}
+	if len(slug) > MaxSlugLength {
+		return fmt.Errorf("slug must be %d characters or less", MaxSlugLength)
+	}
+	return nil
+}
+
+// NumberedSlug returns slug with "-n" appended, shortening slug so that
+// the result stays within MaxSlugLength.
+func NumberedSlug(slug string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	if len(slug)+len(suffix) > MaxSlugLength {
+		slug = strings.TrimRight(slug[:MaxSlugLength-len(suffix)], "-")
+	}
+	return slug + suffix
+}
@@ -0,0 +1,85 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+)
+
+// The expectations follow sanitizeSessionName and buildSessionName in
+// src/utils/tmux.ts, which replace every "." and ":" with "-".
+func TestSanitizeSessionName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"api", "api"},
+		{"foo.js", "foo-js"},
+		{"host:8080", "host-8080"},
+		{"v1.2.3", "v1-2-3"},
+		{"..::", "----"},
+		{"under_score", "under_score"},
+		{"with space", "with space"},
+		{"café.app", "café-app"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SanitizeSessionName(tt.in); got != tt.want {
+			t.Errorf("SanitizeSessionName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestGetSessionName(t *testing.T) {
+	tests := []struct {
+		repo, slug, want string
+	}{
+		{"api", "auth", "api_auth"},
+		{"foo.js", "main", "foo-js_main"},
+		{"foo.js", "fix.v2", "foo-js_fix-v2"},
+		{"a:b", "c:d", "a-b_c-d"},
+	}
+	for _, tt := range tests {
+		if got := GetSessionName(tt.repo, tt.slug); got != tt.want {
+			t.Errorf("GetSessionName(%q, %q) = %q, want %q", tt.repo, tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestGetSlugFromSessionName(t *testing.T) {
+	tests := []struct {
+		session, repo, want string
+	}{
+		{"api_auth", "api", "auth"},
+		{"foo-js_auth", "foo.js", "auth"},
+		{"foo-js_", "foo.js", "main"},
+		{"other_auth", "foo.js", "other_auth"},
+	}
+	for _, tt := range tests {
+		if got := GetSlugFromSessionName(tt.session, tt.repo); got != tt.want {
+			t.Errorf("GetSlugFromSessionName(%q, %q) = %q, want %q", tt.session, tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestNumberedSlug(t *testing.T) {
+	tests := []struct {
+		slug string
+		n    int
+		want string
+	}{
+		{"auth", 2, "auth-2"},
+		{strings.Repeat("a", 30), 2, strings.Repeat("a", 30) + "-2"},
+		{strings.Repeat("a", 32), 2, strings.Repeat("a", 30) + "-2"},
+		{strings.Repeat("a", 32), 10, strings.Repeat("a", 29) + "-10"},
+		// No double hyphen where the cut lands on one
+		{strings.Repeat("a", 29) + "-bb", 2, strings.Repeat("a", 29) + "-2"},
+	}
+	for _, tt := range tests {
+		got := NumberedSlug(tt.slug, tt.n)
+		if got != tt.want {
+			t.Errorf("NumberedSlug(%q, %d) = %q, want %q", tt.slug, tt.n, got, tt.want)
+		}
+		if err := ValidateSlug(got); err != nil {
+			t.Errorf("NumberedSlug(%q, %d) = %q: %v", tt.slug, tt.n, got, err)
+		}
+	}
+}
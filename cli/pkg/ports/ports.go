@@ -0,0 +1,274 @@
+// Package ports reserves a block of TCP ports per worktree, so that the
+// dev servers of several checkouts of one app can run side by side.
+package ports
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+)
+
+// DefaultFrom is where blocks start when the config does not say.
+const DefaultFrom = 3000
+
+const maxPort = 65535
+
+// procNetFiles list the sockets of the machine on Linux. Elsewhere they are
+// missing and no port counts as listening.
+var procNetFiles = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+
+// Registry maps worktree paths to their reserved ports. A block stays with
+// its worktree until the worktree is removed, so the ports are stable.
+type Registry struct {
+	Blocks map[string][]int `json:"blocks"`
+}
+
+// GetRegistryPath returns the path of the registry file.
+func GetRegistryPath() (string, error) {
+	dir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ports.json"), nil
+}
+
+// LoadRegistry reads the registry. A missing file is an empty registry.
+func LoadRegistry() (*Registry, error) {
+	path, err := GetRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{Blocks: map[string][]int{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if r.Blocks == nil {
+		r.Blocks = map[string][]int{}
+	}
+	return r, nil
+}
+
+// Save writes the registry.
+func (r *Registry) Save() error {
+	path, err := GetRegistryPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteStateFile(path, data)
+}
+
+// Lookup returns the ports reserved for the worktree at path, or nil. A
+// nil registry has no ports.
+func (r *Registry) Lookup(path string) []int {
+	if r == nil {
+		return nil
+	}
+	return r.Blocks[filepath.Clean(path)]
+}
+
+// Prune drops the blocks of worktrees that no longer exist.
+func (r *Registry) Prune() {
+	for path := range r.Blocks {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(r.Blocks, path)
+		}
+	}
+}
+
+// Allocate returns the block of the worktree at path, reserving one if it
+// has none of the right size yet. Blocks are aligned to count ports from
+// from; the first one that no other worktree holds and in which no port is
+// listening wins.
+func (r *Registry) Allocate(path string, from, count int, listening map[int]bool) ([]int, error) {
+	path = filepath.Clean(path)
+	if block := r.Blocks[path]; len(block) == count {
+		return block, nil
+	}
+	if from <= 0 {
+		from = DefaultFrom
+	}
+
+	taken := map[int]bool{}
+	for other, block := range r.Blocks {
+		if other == path {
+			continue
+		}
+		for _, port := range block {
+			taken[port] = true
+		}
+	}
+
+	for start := from; start+count-1 <= maxPort; start += count {
+		block := make([]int, 0, count)
+		for port := start; port < start+count && !taken[port] && !listening[port]; port++ {
+			block = append(block, port)
+		}
+		if len(block) == count {
+			r.Blocks[path] = block
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("no free block of %d ports from %d", count, from)
+}
+
+// Reserve returns the block of the worktree at path, reserving one for it
+// as configured and recording it in the registry.
+func Reserve(path string, cfg config.Ports) ([]int, error) {
+	unlock, err := lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	r, err := LoadRegistry()
+	if err != nil {
+		return nil, err
+	}
+	r.Prune()
+
+	if block := r.Lookup(path); len(block) == cfg.Count {
+		return block, nil
+	}
+	block, err := r.Allocate(path, cfg.From, cfg.Count, Listening())
+	if err != nil {
+		return nil, err
+	}
+	return block, r.Save()
+}
+
+// Release frees the block of the worktree at path.
+func Release(path string) error {
+	return update(func(r *Registry) {
+		delete(r.Blocks, filepath.Clean(path))
+	})
+}
+
+// Move hands the block of the worktree at oldPath to newPath, for a
+// worktree that was moved.
+func Move(oldPath, newPath string) error {
+	return update(func(r *Registry) {
+		oldPath, newPath = filepath.Clean(oldPath), filepath.Clean(newPath)
+		if block, ok := r.Blocks[oldPath]; ok {
+			r.Blocks[newPath] = block
+			delete(r.Blocks, oldPath)
+		}
+	})
+}
+
+func update(change func(*Registry)) error {
+	// Without ports configured there is no registry to change, and no
+	// reason to create the state directory for its lock
+	path, err := GetRegistryPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	unlock, err := lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	r, err := LoadRegistry()
+	if err != nil {
+		return err
+	}
+	if len(r.Blocks) == 0 {
+		return nil
+	}
+	change(r)
+	return r.Save()
+}
+
+// lock takes an exclusive lock on the registry until unlock is called, so
+// that two twt processes cannot hand out the same block. It locks a file
+// of its own, since Save replaces the registry file.
+func lock() (unlock func(), err error) {
+	path, err := GetRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	// Closing the file releases the lock
+	return func() { f.Close() }, nil
+}
+
+// EnvNames returns the variable names of a block: PORT for the first port,
+// then PORT_1, PORT_2, ...
+func EnvNames(count int) []string {
+	names := make([]string, count)
+	for i := range names {
+		names[i] = "PORT"
+		if i > 0 {
+			names[i] += "_" + strconv.Itoa(i)
+		}
+	}
+	return names
+}
+
+// Listening returns the TCP ports that sockets of this machine listen on.
+func Listening() map[int]bool {
+	listening := map[int]bool{}
+	for _, file := range procNetFiles {
+		if data, err := os.ReadFile(file); err == nil {
+			parseProcNet(string(data), listening)
+		}
+	}
+	return listening
+}
+
+// tcpListen is the socket state of a listening socket in /proc/net/tcp.
+const tcpListen = "0A"
+
+// parseProcNet adds the listening ports in a /proc/net/tcp or tcp6 table
+// to listening. Lines look like
+//
+//	0: 00000000:0BB8 00000000:0000 0A ...
+func parseProcNet(data string, listening map[int]bool) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[3] != tcpListen {
+			continue
+		}
+		i := strings.LastIndex(fields[1], ":")
+		if i < 0 {
+			continue
+		}
+		if port, err := strconv.ParseInt(fields[1][i+1:], 16, 32); err == nil {
+			listening[int(port)] = true
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package ports
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+)
+
+func TestParseProcNet(t *testing.T) {
+	tcp := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1 1 0 100 0 0 10 0
+   1: 0100007F:0BB9 0100007F:D2F0 01 00000000:00000000 00:00000000 00000000  1000        0 2 1 0 20 4 30 10 -1
+`
+	tcp6 := `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000001000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 3 1 0 100 0 0 10 0
+`
+	listening := map[int]bool{}
+	parseProcNet(tcp, listening)
+	parseProcNet(tcp6, listening)
+
+	// 3001 is only connected, not listening
+	want := map[int]bool{3000: true, 8080: true}
+	if len(listening) != len(want) || !listening[3000] || !listening[8080] {
+		t.Errorf("listening = %v, want %v", listening, want)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	dir := t.TempDir()
+	r := &Registry{Blocks: map[string][]int{
+		filepath.Join(dir, "a"): {3000, 3001, 3002},
+	}}
+
+	// 3005 is busy, so the block 3003-3005 is skipped as a whole
+	block, err := r.Allocate(filepath.Join(dir, "b"), 3000, 3, map[int]bool{3005: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{3006, 3007, 3008}; !slices.Equal(block, want) {
+		t.Errorf("block = %v, want %v", block, want)
+	}
+
+	// The block is kept even once its ports are in use
+	again, _ := r.Allocate(filepath.Join(dir, "b")+"/", 3000, 3, map[int]bool{3006: true})
+	if !slices.Equal(again, block) {
+		t.Errorf("block moved to %v", again)
+	}
+
+	if _, err := r.Allocate(filepath.Join(dir, "c"), 65534, 3, nil); err == nil {
+		t.Error("allocated a block past the last port")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	old := procNetFiles
+	procNetFiles = nil
+	t.Cleanup(func() { procNetFiles = old })
+	worktree := t.TempDir()
+	cfg := config.Ports{From: 4000, Count: 2}
+
+	block, err := Reserve(worktree, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{4000, 4001}; !slices.Equal(block, want) {
+		t.Fatalf("block = %v, want %v", block, want)
+	}
+
+	// A removed worktree gives its block up to the next one
+	gone := filepath.Join(t.TempDir(), "gone")
+	r, _ := LoadRegistry()
+	r.Blocks[gone] = []int{4002, 4003}
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	other := t.TempDir()
+	if block, _ := Reserve(other, cfg); !slices.Equal(block, []int{4002, 4003}) {
+		t.Errorf("block = %v, want the pruned one", block)
+	}
+
+	moved := filepath.Join(t.TempDir(), "moved")
+	if err := os.Rename(other, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := Move(other, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := Release(worktree); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ = LoadRegistry()
+	if r.Lookup(worktree) != nil || !slices.Equal(r.Lookup(moved), []int{4002, 4003}) {
+		t.Errorf("registry = %v", r.Blocks)
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	old := procNetFiles
+	procNetFiles = nil
+	t.Cleanup(func() { procNetFiles = old })
+	cfg := config.Ports{From: 5000, Count: 2}
+
+	blocks := make([][]int, 8)
+	var wg sync.WaitGroup
+	for i := range blocks {
+		worktree := t.TempDir()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if blocks[i], err = Reserve(worktree, cfg); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, block := range blocks {
+		for _, port := range block {
+			if seen[port] {
+				t.Fatalf("port %d was handed out twice: %v", port, blocks)
+			}
+			seen[port] = true
+		}
+	}
+}
+
+func TestEnvNames(t *testing.T) {
+	if got, want := EnvNames(3), []string{"PORT", "PORT_1", "PORT_2"}; !slices.Equal(got, want) {
+		t.Errorf("EnvNames(3) = %v, want %v", got, want)
+	}
+}
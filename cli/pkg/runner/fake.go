@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Step scripts the answer to a command run through a Fake.
+type Step struct {
+	// Argv is matched against the command name followed by its args.
+	// A final "*" matches any remaining arguments.
+	Argv []string
+
+	Stdout   string
+	Stderr   string
+	ExitCode int   // non-zero makes Run return an *ExitError
+	Err      error // returned instead of an ExitError, e.g. context.DeadlineExceeded
+
+	Repeat bool // answer every matching command, not just the first
+}
+
+func (s Step) matches(argv []string) bool {
+	if n := len(s.Argv); n > 0 && s.Argv[n-1] == "*" {
+		return len(argv) >= n-1 && slices.Equal(s.Argv[:n-1], argv[:n-1])
+	}
+	return slices.Equal(s.Argv, argv)
+}
+
+// Fake is a scripted Runner for tests. Each command is answered by the
+// first matching step that has not been used up; commands without a
+// matching step fail with exit status 127. Every command is recorded.
+type Fake struct {
+	*Recorder
+	script *script
+}
+
+// NewFake returns a Fake that answers commands with steps.
+func NewFake(steps ...Step) *Fake {
+	s := &script{steps: steps, used: make([]bool, len(steps))}
+	return &Fake{Recorder: NewRecorder(s), script: s}
+}
+
+// Unused returns the steps without Repeat that never matched a command.
+func (f *Fake) Unused() []Step {
+	f.script.mu.Lock()
+	defer f.script.mu.Unlock()
+
+	var unused []Step
+	for i, step := range f.script.steps {
+		if !step.Repeat && !f.script.used[i] {
+			unused = append(unused, step)
+		}
+	}
+	return unused
+}
+
+type script struct {
+	mu    sync.Mutex
+	steps []Step
+	used  []bool
+}
+
+func (s *script) Run(ctx context.Context, cmd Command) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	argv := cmd.Argv()
+	for i, step := range s.steps {
+		if s.used[i] || !step.matches(argv) {
+			continue
+		}
+		if !step.Repeat {
+			s.used[i] = true
+		}
+
+		res := Result{Stdout: []byte(step.Stdout), Stderr: []byte(step.Stderr), ExitCode: step.ExitCode}
+		switch {
+		case step.Err != nil:
+			res.ExitCode = -1
+			return res, step.Err
+		case step.ExitCode != 0:
+			return res, &ExitError{Command: cmd, Code: step.ExitCode}
+		}
+		return res, nil
+	}
+
+	msg := fmt.Sprintf("runner: unscripted command: %s", cmd)
+	return Result{Stderr: []byte(msg), ExitCode: 127}, &ExitError{Command: cmd, Code: 127}
+}
@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// Record is one command run through a Recorder.
+type Record struct {
+	Command Command
+	Result  Result
+	Err     error
+}
+
+// Recorder wraps a Runner and keeps a Record of every command it runs.
+type Recorder struct {
+	Runner Runner
+
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewRecorder returns a Recorder around r.
+func NewRecorder(r Runner) *Recorder {
+	return &Recorder{Runner: r}
+}
+
+func (r *Recorder) Run(ctx context.Context, cmd Command) (Result, error) {
+	res, err := r.Runner.Run(ctx, cmd)
+
+	r.mu.Lock()
+	r.records = append(r.records, Record{Command: cmd, Result: res, Err: err})
+	r.mu.Unlock()
+	return res, err
+}
+
+// Records returns the commands run so far, oldest first.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Record(nil), r.records...)
+}
+
+// Argvs returns the argv of every command run so far, oldest first.
+func (r *Recorder) Argvs() [][]string {
+	var argvs [][]string
+	for _, rec := range r.Records() {
+		argvs = append(argvs, rec.Command.Argv())
+	}
+	return argvs
+}
@@ -0,0 +1,107 @@
+// Package runner executes external commands such as git and tmux behind an
+// interface, so their callers can be tested with a scripted Fake.
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command describes a single invocation.
+type Command struct {
+	Name  string
+	Args  []string
+	Dir   string // working directory; empty means the current one
+	Stdin []byte // nil means no input
+}
+
+// Argv returns the command name followed by its arguments.
+func (c Command) Argv() []string {
+	return append([]string{c.Name}, c.Args...)
+}
+
+func (c Command) String() string {
+	return strings.Join(c.Argv(), " ")
+}
+
+// Result is the outcome of a command that ran, successfully or not.
+type Result struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int // -1 if the process did not exit on its own
+	Duration time.Duration
+}
+
+// Output returns stdout with surrounding whitespace trimmed.
+func (r Result) Output() string {
+	return strings.TrimSpace(string(r.Stdout))
+}
+
+// Message returns stderr, or stdout when stderr is empty, for use in error
+// messages.
+func (r Result) Message() string {
+	if msg := strings.TrimSpace(string(r.Stderr)); msg != "" {
+		return msg
+	}
+	return r.Output()
+}
+
+// Runner runs commands. Implementations must be safe for concurrent use.
+type Runner interface {
+	// Run runs cmd and waits for it. A non-zero exit status is reported
+	// as an *ExitError alongside the Result.
+	Run(ctx context.Context, cmd Command) (Result, error)
+}
+
+// ExitError reports a command that exited with a non-zero status.
+type ExitError struct {
+	Command Command
+	Code    int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Exec runs commands as real processes.
+type Exec struct{}
+
+// waitDelay bounds how long Run waits for output after the process exits,
+// in case it left a child holding the pipes (as tmux does when it starts
+// a server).
+const waitDelay = time.Second
+
+func (Exec) Run(ctx context.Context, cmd Command) (Result, error) {
+	c := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	c.Dir = cmd.Dir
+	if cmd.Stdin != nil {
+		c.Stdin = bytes.NewReader(cmd.Stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	c.WaitDelay = waitDelay
+
+	start := time.Now()
+	err := c.Run()
+	res := Result{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		ExitCode: c.ProcessState.ExitCode(),
+		Duration: time.Since(start),
+	}
+
+	if ctxErr := ctx.Err(); ctxErr != nil && err != nil {
+		return res, fmt.Errorf("%s: %w", cmd.Name, ctxErr)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && res.ExitCode > 0 {
+		return res, &ExitError{Command: cmd, Code: res.ExitCode}
+	}
+	return res, err
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExec(t *testing.T) {
+	cmd := Command{Name: "sh", Args: []string{"-c", "cat; echo oops >&2; exit 3"}, Stdin: []byte("hello")}
+	res, err := Exec{}.Run(context.Background(), cmd)
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 {
+		t.Fatalf("err = %v, expected exit status 3", err)
+	}
+	if res.Output() != "hello" || res.Message() != "oops" || res.ExitCode != 3 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Duration <= 0 {
+		t.Errorf("Duration = %v, expected it to be measured", res.Duration)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := Exec{}.Run(ctx, Command{Name: "sleep", Args: []string{"5"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, expected context.DeadlineExceeded", err)
+	}
+}
+
+func TestFake(t *testing.T) {
+	fake := NewFake(
+		Step{Argv: []string{"tmux", "has-session", "-t", "=api"}, ExitCode: 1},
+		Step{Argv: []string{"tmux", "new-session", "*"}},
+		Step{Argv: []string{"tmux", "has-session", "-t", "=api"}, Repeat: true},
+		Step{Argv: []string{"git", "--version"}, Stdout: "git version 2.43.0\n"},
+	)
+	ctx := context.Background()
+
+	if _, err := fake.Run(ctx, Command{Name: "tmux", Args: []string{"has-session", "-t", "=api"}}); err == nil {
+		t.Error("first has-session should fail")
+	}
+	if _, err := fake.Run(ctx, Command{Name: "tmux", Args: []string{"new-session", "-d", "-s", "api"}}); err != nil {
+		t.Errorf("new-session: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := fake.Run(ctx, Command{Name: "tmux", Args: []string{"has-session", "-t", "=api"}}); err != nil {
+			t.Errorf("has-session after new-session: %v", err)
+		}
+	}
+
+	var exitErr *ExitError
+	if _, err := fake.Run(ctx, Command{Name: "tmux", Args: []string{"kill-server"}}); !errors.As(err, &exitErr) || exitErr.Code != 127 {
+		t.Errorf("unscripted command: err = %v, expected exit status 127", err)
+	}
+
+	if len(fake.Records()) != 5 {
+		t.Errorf("recorded %d commands, expected 5", len(fake.Records()))
+	}
+	if unused := fake.Unused(); len(unused) != 1 || unused[0].Argv[0] != "git" {
+		t.Errorf("Unused() = %v, expected only the git step", unused)
+	}
+}
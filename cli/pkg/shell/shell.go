@@ -0,0 +1,26 @@
+// Package shell quotes arguments for POSIX sh.
+package shell
+
+import "strings"
+
+// safeChars never need quoting.
+const safeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:@%+,"
+
+// Quote wraps s in single quotes.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Join joins argv into a command line, quoting only the arguments that
+// need it.
+func Join(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && strings.Trim(arg, safeChars) == "" {
+			quoted[i] = arg
+		} else {
+			quoted[i] = Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
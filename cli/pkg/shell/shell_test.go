@@ -0,0 +1,34 @@
+package shell
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":            "''",
+		"plain":       "'plain'",
+		"it's":        `'it'\''s'`,
+		"$HOME; ls *": "'$HOME; ls *'",
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"npm", "run", "dev"}, "npm run dev"},
+		{[]string{"git", "commit", "-m", "fix it"}, "git commit -m 'fix it'"},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"ls", "--color=auto", "~/src"}, "ls --color=auto '~/src'"},
+	}
+	for _, tt := range tests {
+		if got := Join(tt.argv); got != tt.want {
+			t.Errorf("Join(%q) = %s, want %s", tt.argv, got, tt.want)
+		}
+	}
+}
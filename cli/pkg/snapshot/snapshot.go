@@ -0,0 +1,341 @@
+// Package snapshot saves the windows and panes of worktree sessions to a
+// file and creates the sessions again from it, e.g. after the tmux server
+// was restarted.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/shell"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// Version is the version of the snapshot file format.
+const Version = 1
+
+// Snapshot is the saved state of the worktree sessions.
+type Snapshot struct {
+	Version  int       `json:"version"`
+	Created  time.Time `json:"created"`
+	Sessions []Session `json:"sessions"`
+}
+
+// Session is a saved session.
+type Session struct {
+	Name    string   `json:"name"`
+	Workdir string   `json:"workdir"`
+	Server  string   `json:"server"` // tmux.ServerInstance it was saved from
+	Windows []Window `json:"windows"`
+}
+
+// Window is a saved window with its panes in pane order.
+type Window struct {
+	Index  int    `json:"index"`
+	Name   string `json:"name"`
+	Layout string `json:"layout"`
+	Active bool   `json:"active,omitempty"`
+	Panes  []Pane `json:"panes"`
+}
+
+// Pane is a saved pane.
+type Pane struct {
+	Path    string `json:"path"`
+	Command string `json:"command,omitempty"` // foreground command, empty at a shell prompt
+	Active  bool   `json:"active,omitempty"`
+}
+
+// GetPath returns the path of the snapshot file.
+func GetPath() (string, error) {
+	dir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "snapshot.json"), nil
+}
+
+// Take saves the session of every entry that has one.
+func Take(entries []workspace.Entry) (*Snapshot, error) {
+	snap := &Snapshot{Version: Version, Created: time.Now().UTC()}
+	server := ""
+	for _, e := range entries {
+		if e.Session == nil {
+			continue
+		}
+		if server == "" {
+			var err error
+			if server, err = tmux.ServerInstance(); err != nil {
+				return nil, err
+			}
+		}
+
+		panes, err := tmux.ListPanes(e.Session.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Session.Name, err)
+		}
+		session := FromPanes(e.Session.Name, e.Path, panes, ForegroundCommand)
+		session.Server = server
+		snap.Sessions = append(snap.Sessions, session)
+	}
+	return snap, nil
+}
+
+// Carry adds the sessions of prev that were lost with an earlier server
+// and not restored since, so a snapshot taken after a restart does not
+// drop them. Sessions saved from the server that is running now are left
+// out, since they were killed on purpose, as are sessions that are running
+// and those whose worktree is gone.
+func (s *Snapshot) Carry(prev *Snapshot, server string, running func(name string) bool) {
+	if prev == nil {
+		return
+	}
+
+	saved := map[string]bool{}
+	for _, session := range s.Sessions {
+		saved[session.Name] = true
+	}
+	for _, session := range prev.Sessions {
+		if saved[session.Name] || session.Server == server || running(session.Name) {
+			continue
+		}
+		if info, err := os.Stat(session.Workdir); err != nil || !info.IsDir() {
+			continue
+		}
+		s.Sessions = append(s.Sessions, session)
+	}
+}
+
+// FromPanes builds the saved form of a session from its panes, as listed
+// by tmux.ListPanes. command returns the command to run again in a pane.
+func FromPanes(name, workdir string, panes []tmux.Pane, command func(tmux.Pane) string) Session {
+	s := Session{Name: name, Workdir: workdir}
+	for _, p := range panes {
+		if len(s.Windows) == 0 || s.Windows[len(s.Windows)-1].Index != p.WindowIndex {
+			s.Windows = append(s.Windows, Window{
+				Index:  p.WindowIndex,
+				Name:   p.WindowName,
+				Layout: p.WindowLayout,
+				Active: p.WindowActive,
+			})
+		}
+		w := &s.Windows[len(s.Windows)-1]
+		w.Panes = append(w.Panes, Pane{Path: p.Path, Command: command(p), Active: p.Active})
+	}
+	return s
+}
+
+// Load reads a snapshot file.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if snap.Version != Version {
+		return nil, fmt.Errorf("%s has version %d, expected %d", path, snap.Version, Version)
+	}
+	return &snap, nil
+}
+
+// Save writes the snapshot to path. Only the user can read it, since
+// saved command lines may carry secrets.
+func (s *Snapshot) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return config.WriteStateFile(path, data)
+}
+
+// RestoreOptions controls Restore.
+type RestoreOptions struct {
+	Env []layout.Var // set as layout.CreateSession does
+
+	// Run lists the commands to run again, as program names or command
+	// prefixes. Other commands are only typed, so that one captured halfway
+	// through, such as a deploy, waits for Enter instead of running twice.
+	Run []string
+}
+
+// DefaultRunCommands are run again when the config does not list any.
+var DefaultRunCommands = []string{"vi", "vim", "nvim", "nano", "emacs", "hx", "micro", "less", "top", "htop"}
+
+// Runs reports whether command is one to run again.
+func (o RestoreOptions) Runs(command string) bool {
+	for _, allowed := range o.Run {
+		if command == allowed || strings.HasPrefix(command, allowed+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore creates the session again. Panes start in their saved
+// directories, get their saved layout and have their commands typed into
+// the shell.
+func Restore(s Session, opts RestoreOptions) error {
+	if len(s.Windows) == 0 {
+		return fmt.Errorf("%s has no windows", s.Name)
+	}
+
+	if err := restore(s, opts); err != nil {
+		// Don't leave a half-built session behind
+		if tmux.HasSession(s.Name) {
+			_ = tmux.KillSession(s.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func restore(s Session, opts RestoreOptions) error {
+	var activeWindow string
+	for i, w := range s.Windows {
+		panes := w.Panes
+		if len(panes) == 0 {
+			panes = []Pane{{Path: s.Workdir}}
+		}
+
+		var paneID string
+		var err error
+		if i == 0 {
+			paneID, err = tmux.CreateSessionWindow(s.Name, s.Workdir, w.Name, startDir(panes[0], s.Workdir))
+			if err == nil {
+				err = layout.SetEnv(s.Name, paneID, opts.Env)
+			}
+		} else {
+			paneID, err = tmux.NewWindow(s.Name, w.Name, startDir(panes[0], s.Workdir), "")
+		}
+		if err != nil {
+			return fmt.Errorf("window %q: %w", w.Name, err)
+		}
+
+		ids := []string{paneID}
+		for _, p := range panes[1:] {
+			id, err := tmux.SplitWindow(ids[len(ids)-1], tmux.Split{Cwd: startDir(p, s.Workdir)})
+			if err != nil {
+				return fmt.Errorf("window %q: %w", w.Name, err)
+			}
+			ids = append(ids, id)
+		}
+
+		if w.Layout != "" && len(ids) > 1 {
+			if err := tmux.SelectLayout(paneID, w.Layout); err != nil {
+				return fmt.Errorf("window %q: %w", w.Name, err)
+			}
+		}
+
+		for j, p := range panes {
+			if p.Command != "" {
+				send := tmux.TypeKeys
+				if opts.Runs(p.Command) {
+					send = tmux.SendKeys
+				}
+				if err := send(ids[j], p.Command); err != nil {
+					return fmt.Errorf("window %q: %w", w.Name, err)
+				}
+			}
+			if p.Active && len(ids) > 1 {
+				if err := tmux.SelectPane(ids[j]); err != nil {
+					return fmt.Errorf("window %q: %w", w.Name, err)
+				}
+			}
+		}
+		if w.Active {
+			activeWindow = paneID
+		}
+	}
+
+	if activeWindow != "" {
+		return tmux.SelectWindow(activeWindow)
+	}
+	return nil
+}
+
+// startDir returns the saved directory of p, or the worktree if that
+// directory is gone.
+func startDir(p Pane, workdir string) string {
+	if p.Path == "" {
+		return workdir
+	}
+	if _, err := os.Stat(p.Path); err != nil {
+		return workdir
+	}
+	return p.Path
+}
+
+// shells are the commands that count as a prompt where /proc cannot tell
+// what runs in the foreground.
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true,
+}
+
+// procDir is where ForegroundCommand reads processes from.
+var procDir = "/proc"
+
+// ForegroundCommand returns the command line of the job running in the
+// foreground of the pane, or "" when its shell waits at the prompt. On
+// systems without /proc it falls back to the command name tmux reports.
+func ForegroundCommand(p tmux.Pane) string {
+	if p.PID > 0 {
+		if pgid, ok := foregroundGroup(p.PID); ok {
+			if pgid == p.PID {
+				return ""
+			}
+			if argv := cmdline(pgid); len(argv) > 0 {
+				return shell.Join(argv)
+			}
+		}
+	}
+	if shells[p.Command] {
+		return ""
+	}
+	return p.Command
+}
+
+// foregroundGroup returns the foreground process group of the terminal of
+// process pid, from the tpgid field of /proc/<pid>/stat.
+func foregroundGroup(pid int) (int, bool) {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return 0, false
+	}
+
+	// The command name in parentheses may contain spaces, so count fields
+	// from the closing parenthesis: state ppid pgrp session tty_nr tpgid
+	i := strings.LastIndexByte(string(data), ')')
+	if i < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 6 {
+		return 0, false
+	}
+	tpgid, err := strconv.Atoi(fields[5])
+	if err != nil || tpgid <= 0 {
+		return 0, false
+	}
+	return tpgid, true
+}
+
+// cmdline returns the arguments of process pid.
+func cmdline(pid int) []string {
+	data, err := os.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+}
@@ -0,0 +1,183 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/layout"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func TestFromPanes(t *testing.T) {
+	panes := []tmux.Pane{
+		{WindowIndex: 0, WindowName: "editor", WindowActive: true, WindowLayout: "b25f,80x24,0,0,0", Active: true, Command: "nvim", Path: "/src/auth"},
+		{WindowIndex: 1, WindowName: "server", WindowLayout: "c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}", Active: true, Command: "node", Path: "/src/auth/web"},
+		{WindowIndex: 1, WindowName: "server", WindowLayout: "c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}", Index: 1, Command: "bash", Path: "/src/auth"},
+	}
+	s := FromPanes("api_auth", "/src/auth", panes, func(p tmux.Pane) string { return p.Command })
+
+	if len(s.Windows) != 2 {
+		t.Fatalf("got %d windows, expected 2: %+v", len(s.Windows), s.Windows)
+	}
+	if w := s.Windows[0]; w.Name != "editor" || !w.Active || len(w.Panes) != 1 || w.Panes[0].Command != "nvim" {
+		t.Errorf("unexpected window: %+v", w)
+	}
+	if w := s.Windows[1]; w.Index != 1 || w.Active || len(w.Panes) != 2 || !w.Panes[0].Active || w.Panes[1].Path != "/src/auth" {
+		t.Errorf("unexpected window: %+v", w)
+	}
+}
+
+func TestForegroundCommand(t *testing.T) {
+	dir := t.TempDir()
+	old := procDir
+	procDir = dir
+	t.Cleanup(func() { procDir = old })
+
+	write := func(pid, name, content string) {
+		if err := os.MkdirAll(filepath.Join(dir, pid), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, pid, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// pid (comm) state ppid pgrp session tty_nr tpgid ...
+	write("10", "stat", "10 (bash) S 1 10 10 34816 10 4194304 0 0")
+	write("20", "stat", "20 (my shell) S 1 20 20 34817 21 4194304 0 0")
+	write("21", "cmdline", "npm\x00run\x00dev server\x00")
+
+	tests := []struct {
+		pane tmux.Pane
+		want string
+	}{
+		{tmux.Pane{PID: 10, Command: "bash"}, ""},
+		{tmux.Pane{PID: 20, Command: "npm"}, "npm run 'dev server'"},
+		// Without /proc, the name tmux reports is all there is
+		{tmux.Pane{PID: 30, Command: "zsh"}, ""},
+		{tmux.Pane{PID: 30, Command: "htop"}, "htop"},
+	}
+	for _, tt := range tests {
+		if got := ForegroundCommand(tt.pane); got != tt.want {
+			t.Errorf("ForegroundCommand(%+v) = %q, want %q", tt.pane, got, tt.want)
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "snapshot.json")
+	snap := &Snapshot{Version: Version, Sessions: []Session{{Name: "api_auth", Workdir: "/src/auth"}}}
+	if err := snap.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("snapshot file mode = %v, %v; want 0600", info.Mode(), err)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || info.Mode().Perm() != 0700 {
+		t.Errorf("state dir mode = %v, %v; want 0700", info.Mode(), err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Sessions) != 1 || loaded.Sessions[0].Name != "api_auth" {
+		t.Errorf("loaded %+v", loaded)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("Load accepted an unknown version")
+	}
+}
+
+func TestRestore(t *testing.T) {
+	workdir := t.TempDir()
+	web := filepath.Join(workdir, "web")
+	if err := os.Mkdir(web, 0755); err != nil {
+		t.Fatal(err)
+	}
+	layoutString := "c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}"
+
+	fake := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "new-session", "-d", "-s", "api_auth", "-c", workdir, "-P", "-F", "#{pane_id}", "-n", "editor"}, Stdout: "%1\n"},
+		runner.Step{Argv: []string{"tmux", "set-option", "-t", "api_auth", "@workdir", workdir}},
+		runner.Step{Argv: []string{"tmux", "set-environment", "-t", "=api_auth:", "PORT", "3000"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "-l", "--", " export PORT='3000'"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "Enter"}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "-l", "--", "nvim ."}},
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%1", "Enter"}},
+		runner.Step{Argv: []string{"tmux", "new-window", "-d", "-t", "=api_auth:", "-P", "-F", "#{pane_id}", "-n", "server", "-c", web}, Stdout: "%2\n"},
+		// The second pane's directory is gone, so it starts in the worktree
+		runner.Step{Argv: []string{"tmux", "split-window", "-d", "-t", "%2", "-P", "-F", "#{pane_id}", "-c", workdir}, Stdout: "%3\n"},
+		runner.Step{Argv: []string{"tmux", "select-layout", "-t", "%2", layoutString}},
+		// Not on the list, so it waits for Enter
+		runner.Step{Argv: []string{"tmux", "send-keys", "-t", "%2", "-l", "--", "make deploy"}},
+		runner.Step{Argv: []string{"tmux", "select-pane", "-t", "%3"}},
+		runner.Step{Argv: []string{"tmux", "select-window", "-t", "%2"}},
+	)
+	old := tmux.Runner
+	tmux.Runner = fake
+	t.Cleanup(func() { tmux.Runner = old })
+
+	s := Session{Name: "api_auth", Workdir: workdir, Windows: []Window{
+		{Index: 0, Name: "editor", Layout: "b25f,80x24,0,0,0", Panes: []Pane{{Path: workdir, Command: "nvim .", Active: true}}},
+		{Index: 1, Name: "server", Layout: layoutString, Active: true, Panes: []Pane{
+			{Path: web, Command: "make deploy"},
+			{Path: filepath.Join(workdir, "gone"), Active: true},
+		}},
+	}}
+	opts := RestoreOptions{Env: []layout.Var{{Name: "PORT", Value: "3000"}}, Run: []string{"nvim", "npm run dev"}}
+	if err := Restore(s, opts); err != nil {
+		t.Fatalf("%v; ran %q", err, fake.Argvs())
+	}
+	if unused := fake.Unused(); len(unused) != 0 {
+		t.Errorf("commands not run: %v", unused)
+	}
+}
+
+func TestCarry(t *testing.T) {
+	workdir := t.TempDir()
+	prev := &Snapshot{Sessions: []Session{
+		{Name: "api_auth", Workdir: workdir, Server: "1:100"},                     // saved again below
+		{Name: "api_lost", Workdir: workdir, Server: "1:100"},                     // died with the old server
+		{Name: "api_killed", Workdir: workdir, Server: "2:200"},                   // killed on purpose
+		{Name: "api_gone", Workdir: filepath.Join(workdir, "x"), Server: "1:100"}, // worktree removed
+		{Name: "api_other", Workdir: workdir, Server: "1:100"},                    // running, not a worktree's
+	}}
+	snap := &Snapshot{Sessions: []Session{{Name: "api_auth", Workdir: workdir, Server: "2:200"}}}
+
+	snap.Carry(prev, "2:200", func(name string) bool { return name == "api_other" })
+
+	var names []string
+	for _, s := range snap.Sessions {
+		names = append(names, s.Name)
+	}
+	if want := []string{"api_auth", "api_lost"}; !slices.Equal(names, want) {
+		t.Errorf("sessions = %v, want %v", names, want)
+	}
+	if snap.Sessions[0].Server != "2:200" {
+		t.Errorf("the running session was replaced by its old copy")
+	}
+}
+
+func TestRuns(t *testing.T) {
+	opts := RestoreOptions{Run: []string{"nvim", "npm run dev"}}
+	for command, want := range map[string]bool{
+		"nvim":               true,
+		"nvim .":             true,
+		"nvimx":              false,
+		"npm run dev":        true,
+		"npm run dev -- --x": true,
+		"npm run deploy":     false,
+		"git push":           false,
+	} {
+		if got := opts.Runs(command); got != want {
+			t.Errorf("Runs(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package task
+
+import (
+	"os"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// OrphanKind distinguishes the two halves of a task that can be left behind.
+type OrphanKind int
+
+const (
+	// OrphanSession is a session whose working directory no longer exists.
+	OrphanSession OrphanKind = iota
+	// OrphanWorktree is a .worktrees/* checkout with no session.
+	OrphanWorktree
+)
+
+func (k OrphanKind) String() string {
+	if k == OrphanSession {
+		return "session"
+	}
+	return "worktree"
+}
+
+// Orphan is a session or worktree whose counterpart is missing.
+type Orphan struct {
+	Kind        OrphanKind
+	SessionName string
+	Path        string          // missing workdir, or the worktree path
+	Entry       workspace.Entry // set for OrphanWorktree
+}
+
+// Reason describes why the item is an orphan, for display.
+func (o Orphan) Reason() string {
+	if o.Kind == OrphanSession {
+		return "workdir missing: " + o.Path
+	}
+	return "no session"
+}
+
+// FindOrphans compares worktrees with sessions. Only sessions that belong to
+// one of the entries' repositories (by name prefix or a .worktrees workdir)
+// are considered, so unrelated sessions are never reported.
+func FindOrphans(entries []workspace.Entry, sessions []tmux.Session) []Orphan {
+	var orphans []Orphan
+
+	repoNames := make(map[string]bool)
+	for _, e := range entries {
+		repoNames[e.RepoName] = true
+	}
+
+	sessionWorkdirs := make(map[string]bool)
+	for _, s := range sessions {
+		if s.Workdir == "" {
+			continue
+		}
+		sessionWorkdirs[s.Workdir] = true
+
+		if !isManagedSession(s, repoNames) {
+			continue
+		}
+		if _, err := os.Stat(s.Workdir); os.IsNotExist(err) {
+			orphans = append(orphans, Orphan{
+				Kind:        OrphanSession,
+				SessionName: s.Name,
+				Path:        s.Workdir,
+			})
+		}
+	}
+
+	for _, e := range entries {
+		if e.IsRoot || !strings.Contains(e.Path, "/.worktrees/") {
+			continue
+		}
+		if e.HasSession() || sessionWorkdirs[e.Path] {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			Kind:        OrphanWorktree,
+			SessionName: e.SessionName,
+			Path:        e.Path,
+			Entry:       e,
+		})
+	}
+
+	return orphans
+}
+
+func isManagedSession(s tmux.Session, repoNames map[string]bool) bool {
+	if strings.Contains(s.Workdir, "/.worktrees/") {
+		return true
+	}
+	for name := range repoNames {
+		if strings.HasPrefix(s.Name, naming.SanitizeSessionName(name)+"_") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func TestFindOrphans(t *testing.T) {
+	repo := t.TempDir()
+	live := filepath.Join(repo, ".worktrees", "live")
+	lonely := filepath.Join(repo, ".worktrees", "lonely")
+	gone := filepath.Join(repo, ".worktrees", "gone")
+	for _, dir := range []string{live, lonely} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries := []workspace.Entry{
+		{RepoName: "api", RepoPath: repo, Path: repo, SessionName: "api_main", IsRoot: true},
+		{RepoName: "api", RepoPath: repo, Path: live, SessionName: "api_live", Session: &tmux.Session{Name: "api_live"}},
+		{RepoName: "api", RepoPath: repo, Path: lonely, SessionName: "api_lonely"},
+	}
+	sessions := []tmux.Session{
+		{Name: "api_live", Workdir: live},
+		{Name: "api_gone", Workdir: gone},
+		{Name: "scratch", Workdir: "/does/not/exist"}, // not ours
+	}
+
+	orphans := FindOrphans(entries, sessions)
+	if len(orphans) != 2 {
+		t.Fatalf("expected 2 orphans, got %d: %+v", len(orphans), orphans)
+	}
+
+	if orphans[0].Kind != OrphanSession || orphans[0].SessionName != "api_gone" {
+		t.Errorf("expected session orphan api_gone, got %+v", orphans[0])
+	}
+	if orphans[1].Kind != OrphanWorktree || orphans[1].Path != lonely {
+		t.Errorf("expected worktree orphan %s, got %+v", lonely, orphans[1])
+	}
+}
@@ -0,0 +1,93 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/ports"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// RemovalCheck describes what would be lost by removing a task worktree.
+type RemovalCheck struct {
+	Branch   string
+	Status   *git.GitStatus // nil when git status failed
+	Unmerged int            // commits neither pushed nor merged into the base branch
+	Err      error          // set when the checks themselves could not run
+}
+
+// Safe returns true if removing the worktree and its branch loses nothing.
+func (c RemovalCheck) Safe() bool {
+	return c.Err == nil && c.Status != nil && !c.Status.IsDirty() && c.Unmerged == 0
+}
+
+// Problems lists the reasons the removal is not safe, for display.
+func (c RemovalCheck) Problems() []string {
+	var problems []string
+	if c.Err != nil {
+		problems = append(problems, fmt.Sprintf("could not check worktree: %v", c.Err))
+	}
+	if c.Status != nil && c.Status.IsDirty() {
+		problems = append(problems, fmt.Sprintf("uncommitted changes (M:%d A:%d D:%d U:%d)",
+			c.Status.Modified, c.Status.Added, c.Status.Deleted, c.Status.Untracked))
+	}
+	if c.Unmerged > 0 {
+		problems = append(problems, fmt.Sprintf("%d commit(s) on %s not pushed or merged", c.Unmerged, c.Branch))
+	}
+	return problems
+}
+
+// Check inspects a worktree before removal.
+func Check(e workspace.Entry) RemovalCheck {
+	check := RemovalCheck{Branch: e.Branch}
+
+	check.Status, check.Err = git.GetStatus(e.Path)
+	if check.Err != nil {
+		return check
+	}
+
+	if e.Branch != "" {
+		base, _ := git.GetBaseBranch(e.RepoPath)
+		check.Unmerged, check.Err = git.UnmergedCommits(e.RepoPath, e.Branch, base)
+	}
+	return check
+}
+
+// RemoveOptions controls Remove.
+type RemoveOptions struct {
+	Force        bool // remove even with local changes and delete unmerged branches
+	DeleteBranch bool // also delete the task/* branch
+}
+
+// Remove kills the task's session and the sessions grouped with it,
+// removes its worktree and optionally deletes its branch. The main worktree
+// is never removed.
+func Remove(e workspace.Entry, opts RemoveOptions) error {
+	if e.IsRoot {
+		return fmt.Errorf("refusing to remove the main worktree of %s", e.RepoName)
+	}
+
+	if tmux.HasSession(e.SessionName) {
+		if err := tmux.KillSessionGroup(e.SessionName); err != nil {
+			return err
+		}
+	}
+
+	if err := git.RemoveWorktree(e.RepoPath, e.Path, opts.Force); err != nil {
+		return err
+	}
+	workspace.ClearCache()
+	// Stale blocks are pruned on the next reservation anyway
+	_ = ports.Release(e.Path)
+
+	// Only task branches are ours to delete
+	if opts.DeleteBranch && strings.HasPrefix(e.Branch, "task/") {
+		if err := git.DeleteBranch(e.RepoPath, e.Branch, opts.Force); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/ports"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+// RenameOptions controls what Rename changes besides the session.
+type RenameOptions struct {
+	// MoveWorktree also moves the worktree to .worktrees/<slug> and renames
+	// its task/ branch, so the worktree still maps to the renamed session.
+	MoveWorktree bool
+}
+
+// Rename gives the task of e a new slug. The session takes the name
+// naming.GetSessionName derives from the slug and its @workdir follows the
+// worktree. It returns the new session name.
+//
+// Without MoveWorktree only the session changes, and the worktree no longer
+// maps to it by name. With it, a failed branch or session rename moves the
+// worktree back.
+func Rename(e workspace.Entry, slug string, opts RenameOptions) (string, error) {
+	if err := naming.ValidateSlug(slug); err != nil {
+		return "", err
+	}
+	sessionName := naming.GetSessionName(e.RepoName, slug)
+
+	if opts.MoveWorktree {
+		if e.IsRoot {
+			return "", fmt.Errorf("the main worktree cannot be moved")
+		}
+		if SlugTaken(e.RepoPath, slug) {
+			return "", fmt.Errorf("%q is already taken in %s", slug, e.RepoName)
+		}
+	} else {
+		if !e.HasSession() {
+			return "", fmt.Errorf("%s has no session to rename", e.Path)
+		}
+		if tmux.HasSession(sessionName) {
+			return "", fmt.Errorf("session %s already exists", sessionName)
+		}
+	}
+
+	// Steps that fail after the worktree moved undo the earlier ones, so the
+	// worktree, its branch and its session still match
+	path := e.Path
+	var undo []func() error
+	rollback := func(err error) (string, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				return "", fmt.Errorf("%w; rolling back failed too: %v", err, undoErr)
+			}
+		}
+		return "", err
+	}
+
+	if opts.MoveWorktree {
+		path = filepath.Join(e.RepoPath, ".worktrees", slug)
+		if err := git.MoveWorktree(e.RepoPath, e.Path, path); err != nil {
+			return "", err
+		}
+		workspace.ClearCache()
+		// The session keeps its PORT variables, so keep the ports reserved
+		_ = ports.Move(e.Path, path)
+		undo = append(undo, func() error {
+			_ = ports.Move(path, e.Path)
+			return git.MoveWorktree(e.RepoPath, path, e.Path)
+		})
+
+		if strings.HasPrefix(e.Branch, "task/") {
+			branch := "task/" + slug
+			if err := git.RenameBranch(e.RepoPath, e.Branch, branch); err != nil {
+				return rollback(err)
+			}
+			undo = append(undo, func() error {
+				return git.RenameBranch(e.RepoPath, branch, e.Branch)
+			})
+		}
+	}
+
+	if e.HasSession() {
+		if err := tmux.RenameSession(e.SessionName, sessionName); err != nil {
+			return rollback(err)
+		}
+		if err := tmux.SetWorkdir(sessionName, path); err != nil {
+			return sessionName, fmt.Errorf("failed to update @workdir: %w", err)
+		}
+	}
+	return sessionName, nil
+}
+
+// SlugTaken reports whether slug collides with a task branch, a worktree
+// directory or a tmux session of the repository, since any of them
+// colliding would break the task.
+func SlugTaken(repoRoot, slug string) bool {
+	if git.BranchExists(repoRoot, "task/"+slug) {
+		return true
+	}
+	if _, err := os.Stat(filepath.Join(repoRoot, ".worktrees", slug)); err == nil {
+		return true
+	}
+	return tmux.HasSession(naming.GetSessionName(naming.GetRepoName(repoRoot), slug))
+}
@@ -0,0 +1,130 @@
+package task
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+	"github.com/kargnas/tmux-worktree-tui/pkg/workspace"
+)
+
+func TestRenameMovesWorktree(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := filepath.Join(t.TempDir(), "api")
+	oldPath := filepath.Join(repo, ".worktrees", "auth")
+	newPath := filepath.Join(repo, ".worktrees", "login")
+
+	fakeGit := runner.NewFake(
+		runner.Step{Argv: []string{"git", "show-ref", "--verify", "--quiet", "refs/heads/task/login"}, ExitCode: 1},
+		runner.Step{Argv: []string{"git", "worktree", "move", oldPath, newPath}},
+		runner.Step{Argv: []string{"git", "branch", "-m", "task/auth", "task/login"}},
+		runner.Step{Argv: []string{"git", "config", "--get", "branch.task/login.merge"}, Stdout: "refs/heads/task/auth\n"},
+		runner.Step{Argv: []string{"git", "config", "branch.task/login.merge", "refs/heads/task/login"}},
+	)
+	fakeTmux := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "has-session", "-t", "=api_login"}, ExitCode: 1},
+		runner.Step{Argv: []string{"tmux", "rename-session", "-t", "=api_auth", "api_login"}},
+		runner.Step{Argv: []string{"tmux", "set-option", "-t", "api_login", "@workdir", newPath}},
+	)
+	oldGit, oldTmux := git.Runner, tmux.Runner
+	git.Runner, tmux.Runner = fakeGit, fakeTmux
+	t.Cleanup(func() { git.Runner, tmux.Runner = oldGit, oldTmux })
+
+	entry := workspace.Entry{
+		RepoPath:    repo,
+		RepoName:    "api",
+		Path:        oldPath,
+		Branch:      "task/auth",
+		Slug:        "auth",
+		SessionName: "api_auth",
+		Session:     &tmux.Session{Name: "api_auth"},
+	}
+	sessionName, err := Rename(entry, "login", RenameOptions{MoveWorktree: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionName != "api_login" {
+		t.Errorf("session name = %q, want api_login", sessionName)
+	}
+
+	for _, fake := range []*runner.Fake{fakeGit, fakeTmux} {
+		if unused := fake.Unused(); len(unused) != 0 {
+			t.Errorf("commands not run: %v", unused)
+		}
+	}
+}
+
+func TestRenameRollsBack(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	repo := filepath.Join(t.TempDir(), "api")
+	oldPath := filepath.Join(repo, ".worktrees", "auth")
+	newPath := filepath.Join(repo, ".worktrees", "login")
+
+	fakeGit := runner.NewFake(
+		runner.Step{Argv: []string{"git", "show-ref", "--verify", "--quiet", "refs/heads/task/login"}, ExitCode: 1},
+		runner.Step{Argv: []string{"git", "worktree", "move", oldPath, newPath}},
+		runner.Step{Argv: []string{"git", "branch", "-m", "task/auth", "task/login"}},
+		runner.Step{Argv: []string{"git", "config", "--get", "branch.task/login.merge"}, ExitCode: 1},
+		// Undone in reverse once the session rename fails
+		runner.Step{Argv: []string{"git", "branch", "-m", "task/login", "task/auth"}},
+		runner.Step{Argv: []string{"git", "config", "--get", "branch.task/auth.merge"}, ExitCode: 1},
+		runner.Step{Argv: []string{"git", "worktree", "move", newPath, oldPath}},
+	)
+	fakeTmux := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "has-session", "-t", "=api_login"}, ExitCode: 1},
+		runner.Step{Argv: []string{"tmux", "rename-session", "-t", "=api_auth", "api_login"}, ExitCode: 1, Stderr: "can't find session: api_auth"},
+	)
+	oldGit, oldTmux := git.Runner, tmux.Runner
+	git.Runner, tmux.Runner = fakeGit, fakeTmux
+	t.Cleanup(func() { git.Runner, tmux.Runner = oldGit, oldTmux })
+
+	entry := workspace.Entry{
+		RepoPath:    repo,
+		RepoName:    "api",
+		Path:        oldPath,
+		Branch:      "task/auth",
+		Slug:        "auth",
+		SessionName: "api_auth",
+		Session:     &tmux.Session{Name: "api_auth"},
+	}
+	if _, err := Rename(entry, "login", RenameOptions{MoveWorktree: true}); err == nil {
+		t.Fatal("Rename succeeded without the session")
+	}
+	for _, fake := range []*runner.Fake{fakeGit, fakeTmux} {
+		if unused := fake.Unused(); len(unused) != 0 {
+			t.Errorf("commands not run: %v", unused)
+		}
+	}
+}
+
+func TestRenameSessionOnly(t *testing.T) {
+	fakeTmux := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "has-session", "-t", "=api_login"}, ExitCode: 1},
+		runner.Step{Argv: []string{"tmux", "rename-session", "-t", "=api_auth", "api_login"}},
+		runner.Step{Argv: []string{"tmux", "set-option", "-t", "api_login", "@workdir", "/src/api/.worktrees/auth"}},
+	)
+	oldGit, oldTmux := git.Runner, tmux.Runner
+	git.Runner, tmux.Runner = runner.NewFake(), fakeTmux
+	t.Cleanup(func() { git.Runner, tmux.Runner = oldGit, oldTmux })
+
+	entry := workspace.Entry{
+		RepoPath:    "/src/api",
+		RepoName:    "api",
+		Path:        "/src/api/.worktrees/auth",
+		SessionName: "api_auth",
+		Session:     &tmux.Session{Name: "api_auth"},
+	}
+	if _, err := Rename(entry, "login", RenameOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if argvs := fakeTmux.Argvs(); !slices.ContainsFunc(argvs, func(a []string) bool { return a[1] == "rename-session" }) {
+		t.Errorf("session was not renamed: %q", argvs)
+	}
+
+	if _, err := Rename(entry, "Bad Slug", RenameOptions{}); err == nil {
+		t.Error("invalid slug was accepted")
+	}
+}
@@ -0,0 +1,139 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AttachMode is how a client attaches to a session.
+type AttachMode int
+
+const (
+	// AttachShared attaches like a plain `tmux attach`: every client shares
+	// the session's size and current window.
+	AttachShared AttachMode = iota
+	// AttachDetachOthers detaches the session's other clients first.
+	AttachDetachOthers
+	// AttachReadOnly attaches a client that cannot type into the session.
+	AttachReadOnly
+	// AttachGrouped attaches to a new session grouped with the target, so
+	// the client gets its own current window. The grouped session goes
+	// away once its last client leaves.
+	AttachGrouped
+)
+
+var attachModeNames = []string{"shared", "detach", "read-only", "grouped"}
+
+func (m AttachMode) String() string {
+	if int(m) < len(attachModeNames) {
+		return attachModeNames[m]
+	}
+	return fmt.Sprintf("AttachMode(%d)", int(m))
+}
+
+// ParseAttachMode parses a mode name as used in the config. An empty name
+// is AttachShared.
+func ParseAttachMode(name string) (AttachMode, error) {
+	if name == "" {
+		return AttachShared, nil
+	}
+	for i, n := range attachModeNames {
+		if strings.EqualFold(name, n) {
+			return AttachMode(i), nil
+		}
+	}
+	return AttachShared, fmt.Errorf("unknown attach mode %q (%s)", name, strings.Join(attachModeNames, ", "))
+}
+
+// Next returns the mode after m, wrapping around, for cycling through them.
+func (m AttachMode) Next() AttachMode {
+	return (m + 1) % AttachMode(len(attachModeNames))
+}
+
+// AttachFlags returns the attach-session flags for the mode.
+func (m AttachMode) AttachFlags() []string {
+	switch m {
+	case AttachDetachOthers:
+		return []string{"-d"}
+	case AttachReadOnly:
+		return []string{"-r"}
+	}
+	return nil
+}
+
+// GroupedSessionSeparator joins a session name and a number to name the
+// sessions NewGroupedSession creates. Session names twt derives never
+// contain it, so grouped sessions cannot be mistaken for a task's session.
+const GroupedSessionSeparator = "+"
+
+// NewGroupedSession creates a session grouped with sessionName, named
+// "<sessionName>+<n>", and returns its name. It shares the windows of
+// sessionName but keeps its own current window. Once a client is attached,
+// SetDestroyUnattached makes it go away with that client.
+func NewGroupedSession(sessionName, cwd string) (string, error) {
+	name := ""
+	for n := 1; name == "" || HasSession(name); n++ {
+		name = fmt.Sprintf("%s%s%d", sessionName, GroupedSessionSeparator, n)
+	}
+
+	args := []string{"new-session", "-d", "-t", "=" + sessionName, "-s", name}
+	if cwd != "" {
+		args = append(args, "-c", cwd)
+	}
+	if _, err := run(args...); err != nil {
+		return "", fmt.Errorf("failed to create grouped session: %w", err)
+	}
+	return name, nil
+}
+
+// KillSessionGroup kills the named session and the sessions grouped with
+// it, such as those of grouped attaches, which would otherwise keep its
+// windows alive. Only the base of a group, the session the group is named
+// after, takes the group with it; a grouped attach is killed alone.
+func KillSessionGroup(sessionName string) error {
+	sessions, err := ListSessions()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sessions {
+		if s.Group == sessionName && s.Name != sessionName {
+			if err := KillSession(s.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return KillSession(sessionName)
+}
+
+// SetDestroyUnattached makes tmux destroy the session when its last client
+// detaches. tmux destroys a session without clients right away, so set it
+// once a client is attached, or chain DestroyUnattachedArgs after attach.
+func SetDestroyUnattached(sessionName string) error {
+	_, err := run(DestroyUnattachedArgs(sessionName)...)
+	return err
+}
+
+// DestroyUnattachedArgs returns the tmux command SetDestroyUnattached runs.
+func DestroyUnattachedArgs(sessionName string) []string {
+	return []string{"set-option", "-t", "=" + sessionName + ":", "destroy-unattached", "on"}
+}
+
+// DetachOthers detaches every client attached to the session except the
+// client named keep.
+func DetachOthers(sessionName, keep string) error {
+	res, err := run("list-clients", "-t", "="+sessionName, "-F", "#{client_name}")
+	if err != nil {
+		return fmt.Errorf("failed to list clients: %w", err)
+	}
+
+	for _, client := range strings.Split(res.Output(), "\n") {
+		if client == "" || client == keep {
+			continue
+		}
+		if _, err := run("detach-client", "-t", client); err != nil {
+			return fmt.Errorf("failed to detach %s: %w", client, err)
+		}
+	}
+	return nil
+}
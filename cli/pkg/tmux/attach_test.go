@@ -0,0 +1,88 @@
+package tmux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+)
+
+func TestParseAttachMode(t *testing.T) {
+	for _, mode := range []AttachMode{AttachShared, AttachDetachOthers, AttachReadOnly, AttachGrouped} {
+		got, err := ParseAttachMode(mode.String())
+		if err != nil || got != mode {
+			t.Errorf("ParseAttachMode(%q) = %v, %v", mode.String(), got, err)
+		}
+	}
+	if got, err := ParseAttachMode(""); err != nil || got != AttachShared {
+		t.Errorf("ParseAttachMode(\"\") = %v, %v", got, err)
+	}
+	if _, err := ParseAttachMode("exclusive"); err == nil {
+		t.Error("ParseAttachMode should reject unknown modes")
+	}
+	if AttachGrouped.Next() != AttachShared {
+		t.Error("Next should wrap around")
+	}
+}
+
+func TestNewGroupedSession(t *testing.T) {
+	fake := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "has-session", "-t", "=api_auth+1"}},
+		runner.Step{Argv: []string{"tmux", "has-session", "-t", "=api_auth+2"}, ExitCode: 1},
+		runner.Step{Argv: []string{"tmux", "new-session", "-d", "-t", "=api_auth", "-s", "api_auth+2", "-c", "/src/api/.worktrees/auth"}},
+	)
+	old := Runner
+	Runner = fake
+	t.Cleanup(func() { Runner = old })
+
+	name, err := NewGroupedSession("api_auth", "/src/api/.worktrees/auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "api_auth+2" {
+		t.Errorf("got %q, expected the first free name api_auth+2", name)
+	}
+	if unused := fake.Unused(); len(unused) != 0 {
+		t.Errorf("commands not run: %v", unused)
+	}
+}
+
+func TestKillSessionGroup(t *testing.T) {
+	fields := strings.Join(sessionFields, fieldSeparator)
+	list := runner.Step{Argv: []string{"tmux", "list-sessions", "-F", fields}, Stdout: "" +
+		"api_auth|||$1|||2|||0|||0|||0|||0|||api_auth|||3|||/src|||\n" +
+		"api_auth+1|||$2|||2|||1|||0|||0|||0|||api_auth|||3|||/src|||\n" +
+		"api_auth+2|||$3|||2|||1|||0|||0|||0|||api_auth|||3|||/src|||\n" +
+		"api_docs|||$4|||1|||0|||0|||0|||0||||||0|||/src|||\n"}
+
+	tests := []struct {
+		session string
+		killed  []string
+	}{
+		// The base takes its grouped attaches with it
+		{"api_auth", []string{"api_auth+1", "api_auth+2", "api_auth"}},
+		// A grouped attach leaves the base and its siblings alone
+		{"api_auth+1", []string{"api_auth+1"}},
+		{"api_docs", []string{"api_docs"}},
+	}
+	old := Runner
+	t.Cleanup(func() { Runner = old })
+	for _, tt := range tests {
+		steps := []runner.Step{list}
+		for _, name := range tt.killed {
+			steps = append(steps, runner.Step{Argv: []string{"tmux", "kill-session", "-t", "=" + name}})
+		}
+		fake := runner.NewFake(steps...)
+		Runner = fake
+
+		if err := KillSessionGroup(tt.session); err != nil {
+			t.Fatalf("%s: %v; ran %q", tt.session, err, fake.Argvs())
+		}
+		if unused := fake.Unused(); len(unused) != 0 {
+			t.Errorf("%s: commands not run: %v", tt.session, unused)
+		}
+		if n := len(fake.Argvs()); n != len(steps) {
+			t.Errorf("%s: ran %q", tt.session, fake.Argvs())
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package tmux
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notifications a Control client reports. tmux sends others as well; they
+// are dropped.
+const (
+	EventSessionChanged       = "session-changed" // the control client itself moved to another session
+	EventSessionsChanged      = "sessions-changed"
+	EventSessionRenamed       = "session-renamed"
+	EventWindowAdd            = "window-add"
+	EventWindowClose          = "window-close"
+	EventUnlinkedWindowAdd    = "unlinked-window-add"
+	EventUnlinkedWindowClose  = "unlinked-window-close"
+	EventClientSessionChanged = "client-session-changed" // another client attached or switched
+	EventClientDetached       = "client-detached"
+)
+
+var controlEvents = map[string]bool{
+	EventSessionChanged:       true,
+	EventSessionsChanged:      true,
+	EventSessionRenamed:       true,
+	EventWindowAdd:            true,
+	EventWindowClose:          true,
+	EventUnlinkedWindowAdd:    true,
+	EventUnlinkedWindowClose:  true,
+	EventClientSessionChanged: true,
+	EventClientDetached:       true,
+}
+
+// Event is a notification line from a control-mode client, such as
+// "%session-renamed $3 api_auth".
+type Event struct {
+	Name string   // without the leading "%"
+	Args []string // the remaining words; a trailing name may contain spaces
+}
+
+// Control is a tmux control-mode client (`tmux -C`) that reports session
+// and window changes as they happen. tmux only notifies clients attached
+// to a session, so the client attaches read-only to the most recently
+// active one, which then counts it as an attached client.
+//
+// The attach is a real one: it sets that session's session_last_attached
+// and session_activity to the time the client started. The session was
+// already the most recently active, so the session tmux picks for an
+// attach without -t stays the same, but its last-attached time no longer
+// tells when a person last attached. tmux has no way to watch a server
+// without attaching.
+type Control struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	events chan Event
+}
+
+// StartControl attaches a control-mode client to Server. It fails when the
+// server has no sessions.
+func StartControl() (*Control, error) {
+	args := append(Server.Args(), "-C", "attach-session", "-f", "ignore-size,no-output,read-only")
+	cmd := exec.Command("tmux", args...)
+
+	// tmux exits when stdin closes, so it stays open until Close
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start tmux control client: %w", err)
+	}
+
+	c := &Control{cmd: cmd, stdin: stdin, events: make(chan Event, 64)}
+	go c.read(stdout)
+	return c, nil
+}
+
+// Events delivers notifications until the client exits, then is closed.
+func (c *Control) Events() <-chan Event {
+	return c.events
+}
+
+// Close detaches the client and waits for tmux to exit.
+func (c *Control) Close() error {
+	c.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		c.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (c *Control) read(r io.Reader) {
+	defer close(c.events)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	inReply := false
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Command replies are framed by %begin and %end or %error; their
+		// lines may start with "%" too
+		switch {
+		case strings.HasPrefix(line, "%begin "):
+			inReply = true
+			continue
+		case strings.HasPrefix(line, "%end "), strings.HasPrefix(line, "%error "):
+			inReply = false
+			continue
+		case inReply:
+			continue
+		}
+
+		e, ok := ParseEvent(line)
+		if !ok {
+			continue
+		}
+		if e.Name == "exit" {
+			return
+		}
+		if controlEvents[e.Name] {
+			c.events <- e
+		}
+	}
+}
+
+// ParseEvent parses a control-mode notification line. Session names are
+// the last argument and keep their spaces.
+func ParseEvent(line string) (Event, bool) {
+	if !strings.HasPrefix(line, "%") {
+		return Event{}, false
+	}
+	name, rest, _ := strings.Cut(line[1:], " ")
+	if name == "" {
+		return Event{}, false
+	}
+
+	e := Event{Name: name}
+	switch name {
+	case EventSessionChanged, EventSessionRenamed:
+		// %session-changed $id name
+		e.Args = splitArgs(rest, 2)
+	case EventClientSessionChanged:
+		// %client-session-changed client $id name
+		e.Args = splitArgs(rest, 3)
+	default:
+		e.Args = strings.Fields(rest)
+	}
+	return e, true
+}
+
+// splitArgs splits s into at most n space-separated words.
+func splitArgs(s string, n int) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.SplitN(s, " ", n)
+}
+
+// SessionName returns the session name carried by session-changed,
+// session-renamed and client-session-changed events.
+func (e Event) SessionName() string {
+	switch e.Name {
+	case EventSessionChanged, EventSessionRenamed, EventClientSessionChanged:
+		if len(e.Args) > 0 {
+			return e.Args[len(e.Args)-1]
+		}
+	}
+	return ""
+}
+
+// SessionID returns the session ID, such as "$3", carried by the same
+// events as SessionName.
+func (e Event) SessionID() string {
+	switch e.Name {
+	case EventSessionChanged, EventSessionRenamed, EventClientSessionChanged:
+		if len(e.Args) > 1 {
+			return e.Args[len(e.Args)-2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package tmux
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		line    string
+		name    string
+		args    []string
+		session string
+		id      string
+	}{
+		{"%sessions-changed", EventSessionsChanged, nil, "", ""},
+		{"%session-renamed $5 my session", EventSessionRenamed, []string{"$5", "my session"}, "my session", "$5"},
+		{"%client-session-changed /dev/pts/3 $1 api_o2", EventClientSessionChanged, []string{"/dev/pts/3", "$1", "api_o2"}, "api_o2", "$1"},
+		{"%window-add @25", EventWindowAdd, []string{"@25"}, "", ""},
+		{"%client-detached /dev/pts/3", EventClientDetached, []string{"/dev/pts/3"}, "", ""},
+	}
+	for _, tt := range tests {
+		e, ok := ParseEvent(tt.line)
+		if !ok || e.Name != tt.name || !slices.Equal(e.Args, tt.args) || e.SessionName() != tt.session || e.SessionID() != tt.id {
+			t.Errorf("ParseEvent(%q) = %+v, %v (session %q %q)", tt.line, e, ok, e.SessionID(), e.SessionName())
+		}
+	}
+
+	for _, line := range []string{"", "output", "%"} {
+		if _, ok := ParseEvent(line); ok {
+			t.Errorf("ParseEvent(%q) should fail", line)
+		}
+	}
+}
+
+func TestControlRead(t *testing.T) {
+	output := "%begin 1792218284 968 0\n" +
+		"%sessions-changed\n" + // command output, not a notification
+		"%end 1792218284 968 0\n" +
+		"%session-changed $0 api_t1-2\n" +
+		"%output %1 hello\n" +
+		"%window-add @25\n" +
+		"%exit\n" +
+		"%sessions-changed\n"
+
+	c := &Control{events: make(chan Event, 64)}
+	c.read(strings.NewReader(output))
+
+	var names []string
+	for e := range c.events {
+		names = append(names, e.Name)
+	}
+	if want := []string{EventSessionChanged, EventWindowAdd}; !slices.Equal(names, want) {
+		t.Errorf("got events %q, want %q", names, want)
+	}
+}
@@ -0,0 +1,27 @@
+package tmux
+
+import (
+	"context"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+)
+
+// Runner executes tmux commands. Tests replace it with a runner.Fake.
+var Runner runner.Runner = runner.Exec{}
+
+// Timeout bounds every tmux command except those that wait for the user,
+// such as DisplayPopup.
+var Timeout = 10 * time.Second
+
+// run runs tmux with args against Server.
+func run(args ...string) (runner.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	return Runner.Run(ctx, tmuxCommand(args...))
+}
+
+// tmuxCommand builds a tmux command addressed to Server.
+func tmuxCommand(args ...string) runner.Command {
+	return runner.Command{Name: "tmux", Args: append(Server.Args(), args...)}
+}
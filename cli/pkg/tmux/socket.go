@@ -0,0 +1,75 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Socket selects a tmux server. The zero value is tmux's default server.
+type Socket struct {
+	Name string // as for `tmux -L`: a socket in tmux's socket directory
+	Path string // as for `tmux -S`: a full socket path
+}
+
+// Server is the server every command in this package talks to.
+var Server Socket
+
+// ParseSocket reads s as a socket path if it contains a slash, and as a
+// socket name otherwise.
+func ParseSocket(s string) Socket {
+	if strings.Contains(s, "/") {
+		return Socket{Path: s}
+	}
+	return Socket{Name: s}
+}
+
+// SocketFromEnv returns the server twt is running inside, taken from
+// $TMUX, or the default server outside tmux.
+func SocketFromEnv() Socket {
+	path, _, _ := strings.Cut(os.Getenv("TMUX"), ",")
+	return Socket{Path: path}
+}
+
+// Args returns the -L or -S arguments that select the server.
+func (s Socket) Args() []string {
+	switch {
+	case s.Path != "":
+		return []string{"-S", s.Path}
+	case s.Name != "":
+		return []string{"-L", s.Name}
+	}
+	return nil
+}
+
+func (s Socket) String() string {
+	if args := s.Args(); args != nil {
+		return strings.Join(args, " ")
+	}
+	return "default"
+}
+
+// SocketPath returns the socket file of the server, resolving names the
+// way tmux does.
+func (s Socket) SocketPath() string {
+	if s.Path != "" {
+		return s.Path
+	}
+	name := s.Name
+	if name == "" {
+		name = "default"
+	}
+	dir := os.Getenv("TMUX_TMPDIR")
+	if dir == "" {
+		dir = "/tmp"
+	}
+	return filepath.Join(dir, fmt.Sprintf("tmux-%d", os.Getuid()), name)
+}
+
+// IsCurrentServer reports whether twt runs inside a pane of Server, so
+// the current client can be switched instead of attaching a new one.
+func IsCurrentServer() bool {
+	current := SocketFromEnv()
+	return current.Path != "" && filepath.Clean(current.Path) == filepath.Clean(Server.SocketPath())
+}
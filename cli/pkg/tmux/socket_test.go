@@ -0,0 +1,54 @@
+package tmux
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSocket(t *testing.T) {
+	tests := []struct {
+		in   string
+		args []string
+	}{
+		{"", nil},
+		{"work", []string{"-L", "work"}},
+		{"/tmp/tmux-1000/work", []string{"-S", "/tmp/tmux-1000/work"}},
+		{"./work.sock", []string{"-S", "./work.sock"}},
+	}
+	for _, tt := range tests {
+		if got := ParseSocket(tt.in).Args(); !slices.Equal(got, tt.args) {
+			t.Errorf("ParseSocket(%q).Args() = %q, want %q", tt.in, got, tt.args)
+		}
+	}
+}
+
+func TestIsCurrentServer(t *testing.T) {
+	t.Setenv("TMUX_TMPDIR", "/run/user")
+	sock := Socket{Name: "work"}.SocketPath()
+	t.Setenv("TMUX", sock+",1234,0")
+
+	old := Server
+	t.Cleanup(func() { Server = old })
+
+	for _, tt := range []struct {
+		server Socket
+		want   bool
+	}{
+		{Socket{Name: "work"}, true},
+		{Socket{Path: sock}, true},
+		{SocketFromEnv(), true},
+		{Socket{}, false},
+		{Socket{Name: "home"}, false},
+	} {
+		Server = tt.server
+		if got := IsCurrentServer(); got != tt.want {
+			t.Errorf("IsCurrentServer() with Server %s = %v, want %v", tt.server, got, tt.want)
+		}
+	}
+
+	t.Setenv("TMUX", "")
+	Server = Socket{}
+	if IsCurrentServer() {
+		t.Error("IsCurrentServer() outside tmux = true")
+	}
+}
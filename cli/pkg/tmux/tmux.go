@@ -1,114 +1,271 @@
 package tmux
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Session represents a tmux session.
 type Session struct {
+	ID       string // e.g. "$3", kept across renames
 	Name     string
 	Windows  int
 	Attached bool
-	Workdir  string
+	Clients  int    // attached clients, control-mode ones included
+	Workdir  string // @workdir, or Path when the option is unset
+	Path     string // session_path, where new windows start
+
+	HasWorkdir bool // @workdir is set, as on sessions twt or the extension created
+
+	Created      time.Time
+	Activity     time.Time
+	LastAttached time.Time // zero if never attached
+
+	Group     string // empty unless the session is grouped
+	GroupSize int
+}
+
+// sessionFields are the list-sessions format variables, in the order
+// parseSessionLine reads them. The free-form path fields come last.
+var sessionFields = []string{
+	"#{session_name}",
+	"#{session_id}",
+	"#{session_windows}",
+	"#{session_attached}",
+	"#{session_created}",
+	"#{session_activity}",
+	"#{session_last_attached}",
+	"#{session_group}",
+	"#{session_group_size}",
+	"#{session_path}",
+	"#{@workdir}",
+}
+
+// fieldSeparator splits list-sessions fields. Control characters such as
+// tabs are printed as "_" when tmux writes to a terminal, so a printable
+// separator is used instead.
+const fieldSeparator = "|||"
+
+// GetSession returns the session with the given id, such as "$3".
+func GetSession(id string) (Session, error) {
+	res, err := run("list-sessions", "-f", "#{==:#{session_id},"+id+"}", "-F", strings.Join(sessionFields, fieldSeparator))
+	if err != nil {
+		return Session{}, err
+	}
+	sessions := parseSessions(string(res.Stdout))
+	if len(sessions) == 0 {
+		return Session{}, fmt.Errorf("no session %s", id)
+	}
+	return sessions[0], nil
 }
 
-// ListSessions returns a list of all tmux sessions.
+// ListSessions returns a list of all tmux sessions, with a single tmux call.
 func ListSessions() ([]Session, error) {
-	// Format: #{session_name}\t#{session_windows}\t#{session_attached}
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}\t#{session_windows}\t#{session_attached}")
-	output, err := cmd.Output()
+	res, err := run("list-sessions", "-F", strings.Join(sessionFields, fieldSeparator))
 	if err != nil {
 		// If no sessions, tmux returns error (exit status 1)
 		return []Session{}, nil
 	}
+	return parseSessions(string(res.Stdout)), nil
+}
 
+func parseSessions(output string) []Session {
 	var sessions []Session
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-
-	for _, line := range lines {
-		parts := strings.Split(line, "\t")
-		if len(parts) < 3 {
-			continue
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if s, ok := parseSessionLine(line); ok {
+			sessions = append(sessions, s)
 		}
+	}
+	return sessions
+}
 
-		name := parts[0]
+func parseSessionLine(line string) (Session, bool) {
+	parts := strings.SplitN(line, fieldSeparator, len(sessionFields))
+	if len(parts) < len(sessionFields) {
+		return Session{}, false
+	}
 
-		windows := 1
-		if w, err := strconv.Atoi(parts[1]); err == nil {
-			windows = w
-		}
+	windows := 1
+	if w, err := strconv.Atoi(parts[2]); err == nil {
+		windows = w
+	}
+	clients, _ := strconv.Atoi(parts[3])
+	groupSize, _ := strconv.Atoi(parts[8])
 
-		// Get workdir for each session
-		// This is slow if many sessions, but necessary for correct matching
-		workdir, _ := GetSessionWorkdir(name)
+	s := Session{
+		ID:           parts[1],
+		Name:         parts[0],
+		Windows:      windows,
+		Attached:     clients > 0,
+		Clients:      clients,
+		Created:      unixTime(parts[4]),
+		Activity:     unixTime(parts[5]),
+		LastAttached: unixTime(parts[6]),
+		Group:        parts[7],
+		GroupSize:    groupSize,
+		Path:         parts[9],
+		Workdir:      parts[10],
+	}
+	s.HasWorkdir = s.Workdir != ""
+	if !s.HasWorkdir {
+		s.Workdir = s.Path
+	}
+	return s, true
+}
 
-		sessions = append(sessions, Session{
-			Name:     name,
-			Windows:  windows,
-			Attached: parts[2] == "1",
-			Workdir:  workdir,
-		})
+// unixTime parses a tmux timestamp; empty or zero gives the zero time.
+func unixTime(s string) time.Time {
+	sec, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || sec == 0 {
+		return time.Time{}
 	}
+	return time.Unix(sec, 0)
+}
 
-	return sessions, nil
+// GetWorkdirOption returns the @workdir option of a session, or an empty
+// string if it is not set.
+func GetWorkdirOption(sessionName string) (string, error) {
+	res, err := run("show-options", "-t", sessionName, "-v", "@workdir")
+	if err != nil {
+		return "", err
+	}
+	return res.Output(), nil
 }
 
 // GetSessionWorkdir gets the working directory of a session.
 func GetSessionWorkdir(sessionName string) (string, error) {
-	cmd := exec.Command("tmux", "show-options", "-t", sessionName, "-v", "@workdir")
-	output, err := cmd.Output()
-
-	// Fallback to session path if @workdir is not set
-	if err != nil || len(output) == 0 {
-		cmd = exec.Command("tmux", "display-message", "-p", "-t", sessionName, "#{session_path}")
-		output, err = cmd.Output()
+	if workdir, err := GetWorkdirOption(sessionName); err == nil && workdir != "" {
+		return workdir, nil
 	}
 
+	// Fallback to session path if @workdir is not set
+	res, err := run("display-message", "-p", "-t", sessionName, "#{session_path}")
 	if err != nil {
 		return "", err
 	}
 
-	return strings.TrimSpace(string(output)), nil
+	return res.Output(), nil
 }
 
 // CreateSession creates a new detached session.
 func CreateSession(sessionName, cwd string) error {
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", sessionName, "-c", cwd)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create session: %w", err)
+	_, err := CreateSessionWindow(sessionName, cwd, "", cwd)
+	return err
+}
+
+// CreateSessionWindow creates a new detached session for workdir whose
+// first window is named windowName and starts in startDir. It returns the
+// id of the window's pane.
+func CreateSessionWindow(sessionName, workdir, windowName, startDir string) (string, error) {
+	args := []string{"new-session", "-d", "-s", sessionName, "-c", startDir, "-P", "-F", "#{pane_id}"}
+	if windowName != "" {
+		args = append(args, "-n", windowName)
+	}
+	paneID, err := outputLine(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// Set @workdir option for persistence/lookup compatibility
-	cmd = exec.Command("tmux", "set-option", "-t", sessionName, "@workdir", cwd)
-	_ = cmd.Run()
+	_ = SetWorkdir(sessionName, workdir)
+
+	return paneID, nil
+}
+
+// SetWorkdir sets the @workdir option that ties a session to its worktree.
+func SetWorkdir(sessionName, workdir string) error {
+	_, err := run("set-option", "-t", sessionName, "@workdir", workdir)
+	return err
+}
+
+// SetEnvironment sets a variable in the session environment, which every
+// window and pane created afterwards starts with.
+func SetEnvironment(sessionName, name, value string) error {
+	if _, err := run("set-environment", "-t", "="+sessionName+":", name, value); err != nil {
+		return fmt.Errorf("failed to set %s: %w", name, err)
+	}
+	return nil
+}
+
+// RenameSession renames a session.
+func RenameSession(oldName, newName string) error {
+	if _, err := run("rename-session", "-t", "="+oldName, newName); err != nil {
+		return fmt.Errorf("failed to rename session: %w", err)
+	}
+	return nil
+}
+
+// HasSession reports whether a session with exactly this name exists.
+func HasSession(sessionName string) bool {
+	// "=" forces an exact match; a bare -t would also match name prefixes
+	_, err := run("has-session", "-t", "="+sessionName)
+	return err == nil
+}
 
+// KillSession kills the named session.
+func KillSession(sessionName string) error {
+	if _, err := run("kill-session", "-t", "="+sessionName); err != nil {
+		return fmt.Errorf("failed to kill session: %w", err)
+	}
 	return nil
 }
 
-// SwitchClient switches the current client to the target session.
-func SwitchClient(sessionName string) error {
-	cmd := exec.Command("tmux", "switch-client", "-t", sessionName)
-	return cmd.Run()
+// SwitchClient switches the current client to the target session. A
+// target such as "=name:1.0" also selects that window and pane.
+func SwitchClient(target string) error {
+	_, err := run("switch-client", "-t", target)
+	return err
+}
+
+// SwitchClientOf switches the given client to the target session, window
+// or pane. It is needed where there is no current pane, e.g. inside a
+// popup.
+func SwitchClientOf(clientName, target string) error {
+	_, err := run("switch-client", "-c", clientName, "-t", target)
+	return err
+}
+
+// CurrentClient returns the name of the client displaying the current pane.
+func CurrentClient() (string, error) {
+	res, err := run("display-message", "-p", "#{client_name}")
+	if err != nil {
+		return "", err
+	}
+	return res.Output(), nil
 }
 
-// AttachSession attaches to the session (if outside tmux).
-func AttachSession(sessionName string) error {
-	// Check if inside tmux
-	if IsInsideTmux() {
-		return SwitchClient(sessionName)
+// DisplayPopup runs command in a popup on the given client and waits for it
+// to exit. Width and height accept tmux sizes such as "80%" or "120".
+// The popup closes as soon as command exits.
+func DisplayPopup(clientName, width, height, command string) error {
+	args := []string{"display-popup", "-E", "-w", width, "-h", height}
+	if clientName != "" {
+		args = append(args, "-c", clientName)
 	}
+	args = append(args, command)
 
-	// If outside, replace current process with tmux attach
-	// syscall.Exec is better, but for simplicity in this wrapper we'll use Run
-	// Actually, for a TUI app, we might want to just run the command and let it take over stdin/stdout
-	cmd := exec.Command("tmux", "attach", "-t", sessionName)
-	cmd.Stdin = nil // Connect to real stdin/out/err usually?
-	// Bubbletea might interfere. Usually we exit the TUI and then attach.
-	return cmd.Run()
+	// No timeout: the popup stays open as long as the user needs
+	_, err := Runner.Run(context.Background(), tmuxCommand(args...))
+	return err
+}
+
+// ServerInstance identifies the running server by its pid and start time,
+// to tell whether the server was restarted in between.
+func ServerInstance() (string, error) {
+	return outputLine([]string{"display-message", "-p", "#{pid}:#{start_time}"})
+}
+
+// Version returns the output of `tmux -V`, e.g. "tmux 3.4".
+func Version() (string, error) {
+	res, err := run("-V")
+	if err != nil {
+		return "", err
+	}
+	return res.Output(), nil
 }
 
 func IsInsideTmux() bool {
@@ -0,0 +1,160 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/runner"
+)
+
+func TestParseSessions(t *testing.T) {
+	output := "api_auth|||$1|||3|||2|||1700000000|||1700000500|||1700000400|||||||||/src/api|||/src/api/.worktrees/auth\n" +
+		"scratch|||$2|||1|||0|||1700000000|||1700000000||||||work|||2|||/home/me|||\n" +
+		"broken line\n"
+
+	sessions := parseSessions(output)
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, expected 2", len(sessions))
+	}
+
+	auth := sessions[0]
+	if auth.Name != "api_auth" || auth.ID != "$1" || auth.Windows != 3 || !auth.Attached || auth.Clients != 2 {
+		t.Errorf("unexpected session: %+v", auth)
+	}
+	if auth.Workdir != "/src/api/.worktrees/auth" || auth.Path != "/src/api" {
+		t.Errorf("Workdir = %q, Path = %q", auth.Workdir, auth.Path)
+	}
+	if !auth.Created.Equal(time.Unix(1700000000, 0)) || !auth.Activity.Equal(time.Unix(1700000500, 0)) {
+		t.Errorf("Created = %v, Activity = %v", auth.Created, auth.Activity)
+	}
+
+	scratch := sessions[1]
+	if scratch.Attached || !scratch.LastAttached.IsZero() {
+		t.Errorf("scratch should never have been attached: %+v", scratch)
+	}
+	if scratch.Group != "work" || scratch.GroupSize != 2 {
+		t.Errorf("Group = %q, GroupSize = %d", scratch.Group, scratch.GroupSize)
+	}
+	if scratch.Workdir != "/home/me" || scratch.HasWorkdir {
+		t.Errorf("Workdir = %q, expected the session_path fallback", scratch.Workdir)
+	}
+}
+
+func TestCreateSession(t *testing.T) {
+	fake := runner.NewFake(
+		runner.Step{Argv: []string{"tmux", "new-session", "-d", "-s", "api_auth", "-c", "/src/api/.worktrees/auth", "-P", "-F", "#{pane_id}"}, Stdout: "%1\n"},
+		runner.Step{Argv: []string{"tmux", "set-option", "-t", "api_auth", "@workdir", "/src/api/.worktrees/auth"}},
+	)
+	old := Runner
+	Runner = fake
+	t.Cleanup(func() { Runner = old })
+
+	if err := CreateSession("api_auth", "/src/api/.worktrees/auth"); err != nil {
+		t.Fatal(err)
+	}
+	if unused := fake.Unused(); len(unused) != 0 {
+		t.Errorf("commands not run: %v", unused)
+	}
+}
+
+// BenchmarkListSessions measures ListSessions against a private tmux server
+// as the number of sessions grows. The PerSessionLookup variant shows the
+// cost of the former approach, which queried each session's workdir with
+// extra tmux calls.
+func BenchmarkListSessions(b *testing.B) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		b.Skip("tmux not installed")
+	}
+
+	for _, n := range []int{1, 10, 50} {
+		startServer(b, n)
+
+		b.Run(fmt.Sprintf("sessions=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if sessions, _ := ListSessions(); len(sessions) != n {
+					b.Fatalf("listed %d sessions, expected %d", len(sessions), n)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("sessions=%d/PerSessionLookup", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sessions, _ := ListSessions()
+				for _, s := range sessions {
+					_, _ = GetSessionWorkdir(s.Name)
+				}
+			}
+		})
+	}
+}
+
+// startServer starts a tmux server on a temporary socket with n sessions
+// and points tmux commands at it through $TMUX.
+func startServer(b *testing.B, n int) {
+	b.Helper()
+
+	socket := filepath.Join(b.TempDir(), "tmux.sock")
+	tmux := func(args ...string) {
+		b.Helper()
+		if out, err := exec.Command("tmux", append([]string{"-S", socket, "-f", os.DevNull}, args...)...).CombinedOutput(); err != nil {
+			b.Fatalf("tmux %v: %v: %s", args, err, out)
+		}
+	}
+
+	dir := b.TempDir()
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("bench_%d", i)
+		tmux("new-session", "-d", "-s", name, "-c", dir)
+		tmux("set-option", "-t", name, "@workdir", dir)
+	}
+	b.Cleanup(func() { tmux("kill-server") })
+
+	b.Setenv("TMUX", socket+",0,0")
+}
+
+func TestParsePanes(t *testing.T) {
+	output := "%0|||0|||editor|||1|||0|||1|||nvim|||101|||b25f,80x24,0,0,0|||/src/api\n" +
+		"%4|||1|||server|||0|||0|||0|||node|||102|||c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}|||/src/api/web|||x\n" +
+		"%5|||1|||server|||0|||1|||1|||bash|||103|||c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}|||/src/api\n" +
+		"%6|||x|||bad|||0|||0|||0|||bash|||104|||b25f,80x24,0,0,6|||/\n"
+
+	panes := parsePanes(output)
+	if len(panes) != 3 {
+		t.Fatalf("got %d panes, expected 3: %+v", len(panes), panes)
+	}
+	if p := panes[0]; p.ID != "%0" || p.WindowName != "editor" || !p.WindowActive || !p.Active || p.Command != "nvim" || p.PID != 101 {
+		t.Errorf("unexpected pane: %+v", p)
+	}
+	if p := panes[1]; p.WindowIndex != 1 || p.Index != 0 || p.Path != "/src/api/web|||x" || p.WindowLayout != "c1a2,80x24,0,0{40x24,0,0,4,39x24,41,0,5}" {
+		t.Errorf("unexpected pane: %+v", p)
+	}
+	if got := PaneTarget("api_auth", panes[2].ID); got != "=api_auth:.%5" {
+		t.Errorf("PaneTarget = %q", got)
+	}
+}
+
+func TestParseExitStatus(t *testing.T) {
+	tests := []struct {
+		line       string
+		dead       bool
+		status     int
+		noExitCode bool
+	}{
+		{"0::", false, 0, false},
+		{"1:0:", true, 0, false},
+		{"1:3:", true, 3, false},
+		{"1::15", true, 143, false}, // killed by SIGTERM, tmux 3.4+
+		{"1::", true, 0, true},      // not reaped yet, or a signal before tmux 3.4
+	}
+	for _, tt := range tests {
+		dead, status, err := parseExitStatus(tt.line)
+		if dead != tt.dead || status != tt.status || errors.Is(err, ErrNoExitStatus) != tt.noExitCode {
+			t.Errorf("parseExitStatus(%q) = %v, %d, %v", tt.line, dead, status, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FindWindow returns the id of the first window in the session with the
+// given name.
+func FindWindow(sessionName, windowName string) (string, bool) {
+	res, err := run("list-windows", "-t", "="+sessionName, "-F", "#{window_id} #{window_name}")
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range strings.Split(res.Output(), "\n") {
+		id, name, ok := strings.Cut(line, " ")
+		if ok && name == windowName {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// Pane is a pane of a session, as listed by ListPanes.
+type Pane struct {
+	ID           string // e.g. "%3"
+	WindowIndex  int
+	WindowName   string
+	WindowActive bool
+	Index        int
+	Active       bool   // active pane of its window
+	Command      string // pane_current_command
+	PID          int    // pid of the pane's first process, usually a shell
+	WindowLayout string // window_layout, as select-layout accepts it
+	Path         string // pane_current_path
+}
+
+// paneFields are the list-panes format variables, in the order parsePanes
+// reads them. The path comes last since it may contain anything.
+var paneFields = []string{
+	"#{pane_id}",
+	"#{window_index}",
+	"#{window_name}",
+	"#{window_active}",
+	"#{pane_index}",
+	"#{pane_active}",
+	"#{pane_current_command}",
+	"#{pane_pid}",
+	"#{window_layout}",
+	"#{pane_current_path}",
+}
+
+// ListPanes returns every pane of every window in the session, in window
+// and pane order.
+func ListPanes(sessionName string) ([]Pane, error) {
+	res, err := run("list-panes", "-s", "-t", "="+sessionName, "-F", strings.Join(paneFields, fieldSeparator))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes: %w", err)
+	}
+	return parsePanes(string(res.Stdout)), nil
+}
+
+func parsePanes(output string) []Pane {
+	var panes []Pane
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.SplitN(line, fieldSeparator, len(paneFields))
+		if len(parts) < len(paneFields) {
+			continue
+		}
+		windowIndex, err1 := strconv.Atoi(parts[1])
+		index, err2 := strconv.Atoi(parts[4])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		pid, _ := strconv.Atoi(parts[7])
+		panes = append(panes, Pane{
+			ID:           parts[0],
+			WindowIndex:  windowIndex,
+			WindowName:   parts[2],
+			WindowActive: parts[3] == "1",
+			Index:        index,
+			Active:       parts[5] == "1",
+			Command:      parts[6],
+			PID:          pid,
+			WindowLayout: parts[8],
+			Path:         parts[9],
+		})
+	}
+	return panes
+}
+
+// PaneTarget returns the target of a pane of a session by pane id, such as
+// "=api_auth:.%3". Unlike window and pane indexes, the id still finds the
+// pane after windows are moved or panes are closed. The session is kept so
+// that a window shared by grouped sessions is reached through this one.
+func PaneTarget(sessionName, paneID string) string {
+	return "=" + sessionName + ":." + paneID
+}
+
+// NewWindow creates a detached window in the session and returns the id of
+// its pane. An empty command starts the default shell.
+func NewWindow(sessionName, windowName, cwd, command string) (string, error) {
+	args := []string{"new-window", "-d", "-t", "=" + sessionName + ":", "-P", "-F", "#{pane_id}"}
+	if windowName != "" {
+		args = append(args, "-n", windowName)
+	}
+	if cwd != "" {
+		args = append(args, "-c", cwd)
+	}
+	if command != "" {
+		args = append(args, command)
+	}
+	return outputLine(args)
+}
+
+// Split describes the pane SplitWindow creates.
+type Split struct {
+	Cwd        string
+	Command    string // empty starts the default shell
+	Horizontal bool   // side by side instead of stacked
+	Size       string // lines, columns or a percentage; empty halves the pane
+}
+
+// SplitWindow splits the target pane and returns the id of the new pane.
+// The target stays the active pane.
+func SplitWindow(target string, s Split) (string, error) {
+	args := []string{"split-window", "-d", "-t", target, "-P", "-F", "#{pane_id}"}
+	if s.Horizontal {
+		args = append(args, "-h")
+	}
+	if s.Size != "" {
+		args = append(args, "-l", s.Size)
+	}
+	if s.Cwd != "" {
+		args = append(args, "-c", s.Cwd)
+	}
+	if s.Command != "" {
+		args = append(args, s.Command)
+	}
+	return outputLine(args)
+}
+
+// SelectLayout arranges the panes of the target window with a tmux layout
+// such as "even-horizontal" or "main-vertical".
+func SelectLayout(target, layout string) error {
+	if _, err := run("select-layout", "-t", target, layout); err != nil {
+		return fmt.Errorf("failed to select layout %q: %w", layout, err)
+	}
+	return nil
+}
+
+// SelectWindow makes the target window the current window of its session.
+func SelectWindow(target string) error {
+	_, err := run("select-window", "-t", target)
+	return err
+}
+
+// SelectPane makes the target pane the active pane of its window.
+func SelectPane(target string) error {
+	_, err := run("select-pane", "-t", target)
+	return err
+}
+
+// SendKeys types text literally into the target pane and presses Enter.
+func SendKeys(target, text string) error {
+	if err := TypeKeys(target, text); err != nil {
+		return err
+	}
+	_, err := run("send-keys", "-t", target, "Enter")
+	return err
+}
+
+// TypeKeys types text literally into the target pane without pressing
+// Enter, leaving it to the user.
+func TypeKeys(target, text string) error {
+	if _, err := run("send-keys", "-t", target, "-l", "--", text); err != nil {
+		return fmt.Errorf("failed to send keys: %w", err)
+	}
+	return nil
+}
+
+// SetPaneOption sets a pane option such as remain-on-exit.
+func SetPaneOption(target, option, value string) error {
+	_, err := run("set-option", "-p", "-t", target, option, value)
+	return err
+}
+
+// ErrNoExitStatus is returned by PaneExitStatus for a dead pane whose exit
+// status tmux does not know. It appears until tmux has reaped the process,
+// and for good when a signal killed it on tmux versions without
+// pane_dead_signal (before 3.4).
+var ErrNoExitStatus = errors.New("pane has no exit status")
+
+// PaneExitStatus reports whether the pane's process has exited and, if so,
+// its exit status; 128+N for a process killed by signal N, as in the
+// shell. The pane must have remain-on-exit set, or it disappears as soon as
+// the process exits.
+func PaneExitStatus(target string) (dead bool, status int, err error) {
+	line, err := outputLine([]string{"display-message", "-p", "-t", target, "#{pane_dead}:#{pane_dead_status}:#{pane_dead_signal}"})
+	if err != nil {
+		return false, 0, err
+	}
+	return parseExitStatus(line)
+}
+
+func parseExitStatus(line string) (dead bool, status int, err error) {
+	fields := strings.SplitN(line, ":", 3)
+	if fields[0] != "1" {
+		return false, 0, nil
+	}
+	if len(fields) > 1 {
+		if status, err := strconv.Atoi(fields[1]); err == nil {
+			return true, status, nil
+		}
+	}
+	// Older tmux versions expand the unknown pane_dead_signal to nothing
+	if len(fields) > 2 {
+		if signal, err := strconv.Atoi(fields[2]); err == nil && signal > 0 {
+			return true, 128 + signal, nil
+		}
+	}
+	return true, 0, ErrNoExitStatus
+}
+
+// CapturePane returns the full history and visible contents of the pane.
+func CapturePane(target string) (string, error) {
+	res, err := run("capture-pane", "-p", "-J", "-S", "-", "-t", target)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture pane: %w", err)
+	}
+	return string(res.Stdout), nil
+}
+
+// CaptureScreen returns the visible contents of the target pane, with
+// colors and attributes as ANSI escape sequences.
+func CaptureScreen(target string) (string, error) {
+	res, err := run("capture-pane", "-p", "-e", "-t", target)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture pane: %w", err)
+	}
+	return string(res.Stdout), nil
+}
+
+// KillPane kills the target pane.
+func KillPane(target string) error {
+	_, err := run("kill-pane", "-t", target)
+	return err
+}
+
+// SignalChannel wakes up everything blocked in `tmux wait-for channel`.
+func SignalChannel(channel string) error {
+	_, err := run("wait-for", "-S", channel)
+	return err
+}
+
+// outputLine runs tmux and returns the first line of its output.
+func outputLine(args []string) (string, error) {
+	res, err := run(args...)
+	if err != nil {
+		return "", fmt.Errorf("tmux %s failed: %w", args[0], err)
+	}
+	line, _, _ := strings.Cut(string(res.Stdout), "\n")
+	return strings.TrimSpace(line), nil
+}
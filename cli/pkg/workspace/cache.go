@@ -0,0 +1,133 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+// cacheFile is the on-disk form of a discovery result. Session state is
+// never cached; it is matched again on every load.
+type cacheFile struct {
+	Created     time.Time    `json:"created"`
+	SearchPaths []string     `json:"search_paths"`
+	Depth       int          `json:"depth"`
+	Entries     []cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	RepoPath    string `json:"repo_path"`
+	RepoName    string `json:"repo_name"`
+	Path        string `json:"path"`
+	Branch      string `json:"branch"`
+	Slug        string `json:"slug"`
+	SessionName string `json:"session_name"`
+	IsMain      bool   `json:"is_main"`
+	IsRoot      bool   `json:"is_root"`
+}
+
+// GetCachePath returns the path of the discovery cache file.
+func GetCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tmux-worktree-tui", "discovery.json"), nil
+}
+
+// ClearCache drops the discovery cache. Commands that add, move or remove
+// worktrees call it, so that completion does not offer stale ones.
+func ClearCache() {
+	if path, err := GetCachePath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// LoadCached is like Load but reuses a discovery result younger than maxAge.
+// It is meant for latency-sensitive callers such as shell completion. The
+// sessions, listed once, are returned as well, including those that belong
+// to no worktree.
+func LoadCached(cfg *config.Config, maxAge time.Duration) ([]Entry, []tmux.Session) {
+	sessions, _ := tmux.ListSessions()
+
+	path, err := GetCachePath()
+	if err == nil {
+		if cached, ok := readCache(path, cfg, maxAge); ok {
+			return AttachSessions(cached, sessions), sessions
+		}
+	}
+
+	entries := Match(discovery.FindGitRepos(cfg.SearchPaths, cfg.Depth), sessions)
+	if err == nil {
+		writeCache(path, cfg, entries)
+	}
+	return entries, sessions
+}
+
+func readCache(path string, cfg *config.Config, maxAge time.Duration) ([]Entry, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cache cacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, false
+	}
+
+	// A config change invalidates the cache regardless of its age
+	if time.Since(cache.Created) > maxAge || cache.Depth != cfg.Depth || !slices.Equal(cache.SearchPaths, cfg.SearchPaths) {
+		return nil, false
+	}
+
+	entries := make([]Entry, 0, len(cache.Entries))
+	for _, c := range cache.Entries {
+		entries = append(entries, Entry{
+			RepoPath:    c.RepoPath,
+			RepoName:    c.RepoName,
+			Path:        c.Path,
+			Branch:      c.Branch,
+			Slug:        c.Slug,
+			SessionName: c.SessionName,
+			IsMain:      c.IsMain,
+			IsRoot:      c.IsRoot,
+		})
+	}
+	return entries, true
+}
+
+func writeCache(path string, cfg *config.Config, entries []Entry) {
+	cache := cacheFile{
+		Created:     time.Now(),
+		SearchPaths: cfg.SearchPaths,
+		Depth:       cfg.Depth,
+	}
+	for _, e := range entries {
+		cache.Entries = append(cache.Entries, cacheEntry{
+			RepoPath:    e.RepoPath,
+			RepoName:    e.RepoName,
+			Path:        e.Path,
+			Branch:      e.Branch,
+			Slug:        e.Slug,
+			SessionName: e.SessionName,
+			IsMain:      e.IsMain,
+			IsRoot:      e.IsRoot,
+		})
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	// The cache is best effort; failing to write it only costs speed
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(path, data, 0644)
+}
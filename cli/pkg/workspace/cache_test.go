@@ -0,0 +1,28 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+)
+
+func TestClearCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path, err := GetCachePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{SearchPaths: []string{"~/src"}, Depth: 2}
+	writeCache(path, cfg, []Entry{{RepoName: "api", Path: "/src/api", Slug: "main"}})
+
+	if entries, ok := readCache(path, cfg, time.Hour); !ok || len(entries) != 1 {
+		t.Fatalf("readCache = %v, %v; want the written entry", entries, ok)
+	}
+	ClearCache()
+	if _, ok := readCache(path, cfg, time.Hour); ok {
+		t.Error("the cache survived ClearCache")
+	}
+	// Clearing a missing cache is fine
+	ClearCache()
+}
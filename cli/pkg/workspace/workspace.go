@@ -0,0 +1,151 @@
+package workspace
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/config"
+	"github.com/kargnas/tmux-worktree-tui/pkg/discovery"
+	"github.com/kargnas/tmux-worktree-tui/pkg/git"
+	"github.com/kargnas/tmux-worktree-tui/pkg/naming"
+	"github.com/kargnas/tmux-worktree-tui/pkg/recent"
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+// Entry is a single worktree of a discovered repository, paired with
+// the tmux session that belongs to it (if any).
+type Entry struct {
+	RepoPath    string
+	RepoName    string
+	Path        string
+	Branch      string
+	Slug        string
+	SessionName string
+	IsMain      bool
+	IsRoot      bool
+	Session     *tmux.Session // nil when no session is running
+
+	// Filled in by LoadDetails
+	Status     *git.GitStatus // nil when git status failed
+	RecentTime time.Time
+}
+
+// HasSession returns true if a tmux session exists for this worktree.
+func (e Entry) HasSession() bool {
+	return e.Session != nil
+}
+
+// IsDirty returns true if the worktree has uncommitted changes.
+func (e Entry) IsDirty() bool {
+	return e.Status != nil && e.Status.IsDirty()
+}
+
+// LoadDetails fills in the slower per-worktree fields: git status and the
+// most recent activity time.
+func (e *Entry) LoadDetails() {
+	e.Status, _ = git.GetStatus(e.Path)
+	e.RecentTime = recent.GetCombinedRecentTime(e.Path)
+}
+
+// Load discovers every repository under the configured search paths and
+// returns one Entry per worktree, in discovery order.
+func Load(cfg *config.Config) []Entry {
+	return LoadRepos(discovery.FindGitRepos(cfg.SearchPaths, cfg.Depth))
+}
+
+// LoadRepo returns the entries of a single repository.
+func LoadRepo(repoRoot string) []Entry {
+	return LoadRepos([]string{repoRoot})
+}
+
+// LoadRepos returns one Entry per worktree of the given repositories.
+func LoadRepos(repos []string) []Entry {
+	tmuxSessions, _ := tmux.ListSessions()
+	return Match(repos, tmuxSessions)
+}
+
+// Match lists the worktrees of the given repositories and pairs each one
+// with its session from tmuxSessions.
+func Match(repos []string, tmuxSessions []tmux.Session) []Entry {
+	var entries []Entry
+	for _, repoPath := range repos {
+		repoName := naming.GetRepoName(repoPath)
+		wts, _ := git.ListWorktrees(repoPath)
+
+		for _, wt := range wts {
+			slug := naming.GetSlugFromWorktree(wt.Path, repoName, wt.IsMain)
+			entry := Entry{
+				RepoPath:    repoPath,
+				RepoName:    repoName,
+				Path:        wt.Path,
+				Branch:      wt.Branch,
+				Slug:        slug,
+				SessionName: naming.GetSessionName(repoName, slug),
+				IsMain:      wt.IsMain,
+				IsRoot:      naming.IsRoot(slug, repoName, wt.Path, wt.IsMain),
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return AttachSessions(entries, tmuxSessions)
+}
+
+// AttachSessions pairs entries with their running sessions, replacing
+// whatever session they had before. A session is found by the name
+// naming.GetSessionName gives the worktree or, failing that, by its
+// @workdir, which keeps sessions whose name tmux rewrote (or that were
+// renamed by hand) attached to their worktree. SessionName is set to the
+// name of the session found.
+func AttachSessions(entries []Entry, sessions []tmux.Session) []Entry {
+	byName := make(map[string]tmux.Session)
+	for _, s := range sessions {
+		byName[s.Name] = s
+	}
+
+	claimed := make(map[string]bool)
+	for i := range entries {
+		e := &entries[i]
+		e.SessionName = naming.GetSessionName(e.RepoName, e.Slug)
+		e.Session = nil
+		if s, ok := byName[e.SessionName]; ok {
+			e.Session = &s
+			claimed[s.Name] = true
+		}
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if e.Session != nil {
+			continue
+		}
+		if s, ok := SessionForWorkdir(sessions, e.Path, claimed); ok {
+			e.Session = &s
+			e.SessionName = s.Name
+			claimed[s.Name] = true
+		}
+	}
+	return entries
+}
+
+// SessionForWorkdir returns the first session whose @workdir is path,
+// skipping the sessions in exclude. Sessions without @workdir are ignored:
+// their start directory says nothing about which worktree they belong to.
+func SessionForWorkdir(sessions []tmux.Session, path string, exclude map[string]bool) (tmux.Session, bool) {
+	path = filepath.Clean(path)
+	for _, s := range sessions {
+		if s.HasWorkdir && !exclude[s.Name] && filepath.Clean(s.Workdir) == path {
+			return s, true
+		}
+	}
+	return tmux.Session{}, false
+}
+
+// LoadConfig loads the user config, falling back to defaults on error.
+func LoadConfig() *config.Config {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return &config.Config{Depth: 2}
+	}
+	return cfg
+}
@@ -0,0 +1,56 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/kargnas/tmux-worktree-tui/pkg/tmux"
+)
+
+func TestAttachSessions(t *testing.T) {
+	entries := []Entry{
+		{RepoName: "foo.js", Slug: "main", Path: "/src/foo.js"},
+		{RepoName: "foo.js", Slug: "fix.v2", Path: "/src/foo.js/.worktrees/fix.v2"},
+		{RepoName: "foo.js", Slug: "docs", Path: "/src/foo.js/.worktrees/docs"},
+		{RepoName: "foo.js", Slug: "idle", Path: "/src/foo.js/.worktrees/idle"},
+	}
+	sessions := []tmux.Session{
+		{Name: "foo-js_main", Workdir: "/src/foo.js", HasWorkdir: true},
+		// Created before names were sanitized; tmux turned "." into "_"
+		{Name: "foo_js_fix_v2", Workdir: "/src/foo.js/.worktrees/fix.v2/", HasWorkdir: true},
+		// Started in the worktree by hand, without @workdir
+		{Name: "scratch", Workdir: "/src/foo.js/.worktrees/docs"},
+	}
+
+	entries = AttachSessions(entries, sessions)
+
+	for _, tt := range []struct {
+		entry   Entry
+		session string
+	}{
+		{entries[0], "foo-js_main"},
+		{entries[1], "foo_js_fix_v2"},
+		{entries[2], ""},
+		{entries[3], ""},
+	} {
+		got := ""
+		if tt.entry.Session != nil {
+			got = tt.entry.Session.Name
+		}
+		if got != tt.session {
+			t.Errorf("%s: paired with %q, want %q", tt.entry.Slug, got, tt.session)
+		}
+	}
+
+	if entries[1].SessionName != "foo_js_fix_v2" {
+		t.Errorf("SessionName = %q, expected the name of the session found by @workdir", entries[1].SessionName)
+	}
+	if entries[2].SessionName != "foo-js_docs" {
+		t.Errorf("SessionName = %q, want foo-js_docs", entries[2].SessionName)
+	}
+
+	// Once the session is gone the worktree goes back to its own name
+	entries = AttachSessions(entries, sessions[:1])
+	if entries[1].SessionName != "foo-js_fix-v2" || entries[1].Session != nil {
+		t.Errorf("after the session ended: SessionName = %q, Session = %v", entries[1].SessionName, entries[1].Session)
+	}
+}